@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+// runCommand 执行子命令
+func runCommand(cfg *config.Config, name string, args []string) error {
+	switch name {
+	case "import-json":
+		return importJSON(cfg, args)
+	case "resume":
+		return resumeFeed(cfg, args)
+	case "deadletters":
+		return deadLetters(cfg, args)
+	case "import-opml":
+		return importOPML(cfg, args)
+	case "export-opml":
+		return exportOPML(cfg, args)
+	case "discover":
+		return discoverFeeds(cfg, args)
+	default:
+		return fmt.Errorf("未知命令: %s", name)
+	}
+}
+
+// importJSON 将旧版 rss_output 目录中的 JSON 数据导入到配置的存储中。
+// 目标就是该目录时只需以快照为每个源建立已见条目记录
+func importJSON(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import-json", flag.ContinueOnError)
+	from := fs.String("from", "rss_output", "JSON 数据目录")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if cfg.Store.Type == store.TypeJSON && filepath.Clean(cfg.Store.Path) == filepath.Clean(*from) {
+		migrated, err := store.NewJSONStore(*from).MigrateSeen()
+		if err != nil {
+			return err
+		}
+		log.Info("迁移完成: 为 %d 个源建立已见条目记录 -> %s", migrated, *from)
+		return nil
+	}
+
+	dst, err := store.Open(cfg.Store)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if err := store.Import(dst, store.NewJSONStore(*from)); err != nil {
+		return err
+	}
+	log.Info("导入完成: %s -> %s(%s)", *from, cfg.Store.Type, cfg.Store.Path)
+	return nil
+}
+
+// resumeFeed 清除源的失败记录，恢复被自动暂停的源。json 存储可以在常驻进程运行时执行，
+// bolt 存储需先停止常驻进程
+func resumeFeed(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: resume <源名称>")
+	}
+	st, err := store.Open(cfg.Store)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	for _, name := range args {
+		h, err := st.Health(constants.AgentName(name))
+		if err != nil {
+			return err
+		}
+		if err := st.SaveHealth(constants.AgentName(name), h.Resume()); err != nil {
+			return err
+		}
+		log.Info("已恢复源: %s", name)
+	}
+	return nil
+}
+
+// deadLetters 查看或重放死信
+//
+//	deadletters               列出所有死信
+//	deadletters replay <id>   将指定死信移回发件箱，all 表示全部
+func deadLetters(cfg *config.Config, args []string) error {
+	st, err := store.Open(cfg.Store)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	dead, err := st.DeadLetters()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		for _, msg := range dead {
+			fmt.Printf("%d\t%s\t%s\t%d 条\t尝试 %d 次\t%s\t%s\n", msg.ID, msg.Feed, msg.Channel,
+				len(msg.Data.Items), msg.Attempts, msg.CreatedAt.Format(time.DateTime), msg.LastError)
+		}
+		log.Info("共 %d 条死信", len(dead))
+		return nil
+	}
+	if args[0] != "replay" || len(args) < 2 {
+		return fmt.Errorf("用法: deadletters [replay <id>|all]")
+	}
+
+	var ids []uint64
+	if args[1] == "all" {
+		for _, msg := range dead {
+			ids = append(ids, msg.ID)
+		}
+	} else {
+		for _, arg := range args[1:] {
+			id, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				return fmt.Errorf("无效的死信 ID: %s", arg)
+			}
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		if err := st.ReplayDeadLetter(id); err != nil {
+			return err
+		}
+		log.Info("已将死信 %d 移回发件箱", id)
+	}
+	return nil
+}
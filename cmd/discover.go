@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/discover"
+	"github.com/weirwei/rss-agent/internal/httpclient"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/opml"
+)
+
+// stdin 交互选择时读取的输入
+var stdin io.Reader = os.Stdin
+
+// discoverFeeds 从网站地址发现源，列出验证通过的源并选择一个添加到订阅文件
+//
+//	discover [-pick 序号] [-out 订阅文件] [-enabled=false] [-send] [-channels a,b] <网站地址>
+func discoverFeeds(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("discover", flag.ContinueOnError)
+	pick := fs.Int("pick", 0, "直接添加第几个源，为 0 时交互选择，为 -1 时只列出不添加")
+	opts := addSubscriptionFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: discover [-pick 序号] [-out 订阅文件] [-enabled=false] [-send] [-channels a,b] <网站地址>")
+	}
+	siteURL := fs.Arg(0)
+	if !strings.Contains(siteURL, "://") {
+		siteURL = "https://" + siteURL
+	}
+
+	httpClient, err := httpclient.NewHTTPClient(cfg.HTTP)
+	if err != nil {
+		return err
+	}
+	candidates, err := discover.Discover(context.Background(), httpclient.New(httpClient, nil), siteURL)
+	if err != nil {
+		return err
+	}
+	var valid []discover.Candidate
+	for _, c := range candidates {
+		if c.Valid() {
+			valid = append(valid, c)
+		}
+	}
+	if len(valid) == 0 {
+		return fmt.Errorf("未发现可用的源，共尝试 %d 个地址", len(candidates))
+	}
+	for i, c := range valid {
+		fmt.Printf("%d\t%s\t%s\t%d 条\t%s\n", i+1, c.Source, c.Title, c.Items, c.URL)
+	}
+
+	choice := *pick
+	if choice == 0 {
+		fmt.Print("输入序号添加到订阅文件，直接回车或输入 0 跳过: ")
+		line, _ := bufio.NewReader(stdin).ReadString('\n')
+		if choice, err = parseChoice(line); err != nil {
+			return err
+		}
+	}
+	if choice <= 0 {
+		return nil
+	}
+	if choice > len(valid) {
+		return fmt.Errorf("无效的序号: %d", choice)
+	}
+
+	c := valid[choice-1]
+	added, _, err := opts.add(cfg, []opml.Feed{{Title: c.Title, XMLURL: c.URL}})
+	if err != nil {
+		return err
+	}
+	if added == 0 {
+		log.Info("源已存在: %s", c.URL)
+		return nil
+	}
+	log.Info("已添加源 %s: %s -> %s", c.Title, c.URL, *opts.out)
+	opts.remind(cfg)
+	return nil
+}
+
+// parseChoice 解析交互输入的序号，空输入返回 0 表示跳过
+func parseChoice(line string) (int, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return 0, nil
+	}
+	choice, err := strconv.Atoi(line)
+	if err != nil || choice < 0 {
+		return 0, fmt.Errorf("无效的序号: %s", line)
+	}
+	return choice, nil
+}
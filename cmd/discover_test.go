@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/config"
+)
+
+func TestDiscoverPromptSkip(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<html><head><link rel="alternate" type="application/atom+xml" href="/atom.xml"></head></html>`))
+	})
+	mux.HandleFunc("/atom.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title>
+<entry><title>a</title><id>1</id><link href="https://example.com/a"/><updated>2024-09-30T08:00:00Z</updated></entry></feed>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	defer func(r io.Reader) { stdin = r }(stdin)
+	out := filepath.Join(t.TempDir(), "subscriptions.yaml")
+	for _, input := range []string{"0\n", "\n", ""} {
+		stdin = strings.NewReader(input)
+		if err := discoverFeeds(&config.Config{}, []string{"-out", out, server.URL + "/"}); err != nil {
+			t.Fatalf("输入 %q 应跳过: %v", input, err)
+		}
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Fatalf("输入 %q 不应写入订阅文件", input)
+		}
+	}
+
+	for _, input := range []string{"2\n", "-1\n", "abc\n"} {
+		stdin = strings.NewReader(input)
+		if err := discoverFeeds(&config.Config{}, []string{"-out", out, server.URL + "/"}); err == nil {
+			t.Errorf("输入 %q 应返回错误", input)
+		}
+	}
+
+	stdin = strings.NewReader("1\n")
+	if err := discoverFeeds(&config.Config{}, []string{"-out", out, server.URL + "/"}); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(written), server.URL+"/atom.xml") {
+		t.Errorf("订阅文件未包含选择的源:\n%s", written)
+	}
+}
@@ -4,13 +4,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/weirwei/rss-agent/internal/agent"
 	"github.com/weirwei/rss-agent/internal/config"
 	"github.com/weirwei/rss-agent/internal/constants"
 	"github.com/weirwei/rss-agent/internal/fetcher"
+	"github.com/weirwei/rss-agent/internal/httpclient"
 	"github.com/weirwei/rss-agent/internal/log"
 	"github.com/weirwei/rss-agent/internal/service"
+	"github.com/weirwei/rss-agent/internal/store"
 )
 
 func main() {
@@ -20,48 +23,176 @@ func main() {
 		log.Fatal("加载配置失败: %v", err)
 	}
 
+	// 子命令
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal("执行命令失败 %s: %v", os.Args[1], err)
+		}
+		return
+	}
+
+	// 打开状态存储
+	st, err := store.Open(cfg.Store)
+	if err != nil {
+		log.Fatal("打开存储失败: %v", err)
+	}
+	defer st.Close()
+
+	// 所有抓取器和代理共享的 HTTP 客户端
+	httpClient, err := httpclient.NewHTTPClient(cfg.HTTP)
+	if err != nil {
+		log.Fatal("创建 HTTP 客户端失败: %v", err)
+	}
+
+	// 抓取器共享的 HTTP 层
+	client := httpclient.New(httpClient, st)
+
 	// 初始化 RSS 助手
-	rssHelper := service.NewRSSHelper("")
+	rssHelper := service.NewRSSHelper(st, client, cfg.Fetcher)
+
+	// 根据配置创建所有发送渠道
+	channels, err := agent.NewChannels(cfg.Channels, httpClient)
+	if err != nil {
+		log.Fatal("创建发送渠道失败: %v", err)
+	}
+
+	// 源故障告警
+	if cfg.Fetcher.Health.Alert != "" {
+		alert, ok := channels[cfg.Fetcher.Health.Alert]
+		if !ok {
+			log.Fatal("未找到告警渠道: %s", cfg.Fetcher.Health.Alert)
+		}
+		rssHelper.SetAlertAgent(alert)
+	}
+
+	// 初始化发件箱，抓取到的增量先落盘再发送
+	outbox := service.NewOutbox(st, cfg.Outbox)
+	for name, ag := range channels {
+		outbox.Register(name, ag)
+	}
 
 	// 初始化 Agent 助手
-	agentHelper := service.NewAgentHelper("")
+	agentHelper := service.NewAgentHelper(st)
 
-	// 初始化飞书代理
-	phFeishu := agent.NewPHFeishu(cfg.Feishu[constants.AgentTypePH])
+	// 初始化汇总发送服务，开启汇总的渠道按 cron 发送上次汇总以来的新增条目
+	digestHelper := service.NewDigestHelper(st, outbox)
+	for _, channelCfg := range cfg.Channels {
+		if channelCfg.Digest.Enabled {
+			digestHelper.AddChannel(channelCfg.Name, channelCfg.Digest, channelCfg.Cron)
+		}
+	}
 
-	// 创建 ProductHunt 抓取器
-	phFetcher := fetcher.NewPHFetcher()
+	// 按内容路由：配置了路由规则时，源的增量经路由器分发到各渠道，源自己的即时渠道作为默认路由
+	var router *service.Router
+	if len(cfg.Routing.Rules) > 0 {
+		if router, err = service.NewRouter(st, outbox, cfg.Routing); err != nil {
+			log.Fatal("创建路由器失败: %v", err)
+		}
+		router.SetDigest(digestHelper)
+	}
+
+	// bindChannels 将源绑定到渠道：开启汇总的渠道将增量写入汇总队列，配置了 cron 的渠道按计划发送最新快照中未发送过的条目，
+	// 其余渠道立即发送增量
+	bindChannels := func(feed constants.AgentName, names []string, formatterName string, card *config.CardConfig, tmpl *config.TemplateConfig) []agent.Agent {
+		var formatter agent.DataFormatter
+		if formatterName != "" {
+			var ok bool
+			if formatter, ok = agent.GetFormatter(formatterName); !ok {
+				log.Fatal("未找到格式化器 %s: %s", feed, formatterName)
+			}
+		}
+		// 源的卡片布局和模板对路由到的渠道同样生效
+		layoutChannels := names
+		if router != nil {
+			layoutChannels = append(append([]string{}, names...), router.Channels()...)
+		}
+		for _, name := range layoutChannels {
+			if setter, ok := channels[name].(agent.CardSetter); ok && card != nil {
+				setter.SetCard(string(feed), *card)
+			}
+			if setter, ok := channels[name].(agent.TemplateSetter); ok && tmpl != nil {
+				setter.SetTemplate(string(feed), tmpl.Compiled)
+			}
+		}
+		var immediate []agent.Agent
+		var immediateNames []string
+		for _, name := range names {
+			channelCfg, ok := cfg.Channel(name)
+			if !ok {
+				log.Fatal("未找到渠道 %s: %s", feed, name)
+			}
+			if channelCfg.Digest.Enabled {
+				ag := digestHelper.Agent(feed, name)
+				ag.SetFormatter(formatter)
+				immediate = append(immediate, ag)
+				immediateNames = append(immediateNames, name)
+				continue
+			}
+			if channelCfg.Cron != "" {
+				agentHelper.AddAgent(feed, agent.WithFormatter(channels[name], formatter), channelCfg)
+				continue
+			}
+			ag := outbox.Agent(feed, name)
+			ag.SetFormatter(formatter)
+			immediate = append(immediate, ag)
+			immediateNames = append(immediateNames, name)
+		}
+		if router != nil {
+			router.AddFeed(feed, immediateNames, formatter)
+			return []agent.Agent{router.Agent(feed)}
+		}
+		return immediate
+	}
 
 	// 添加动态源
 	if cfg.Fetcher.ProductHunt.Enabled {
-		rssHelper.AddFeed(agent.AgentPHFeishu, phFetcher, config.FeedConfig{
-			Dynamic:  true,
-			Template: "https://decohack.com/producthunt-daily-{{date}}/",
-			Format:   "2006-01-02",
+		agents := bindChannels(constants.AgentPH, cfg.Fetcher.ProductHunt.Channels, "", nil, nil)
+		phFetcher, err := fetcher.NewHTMLFetcher(client, cfg.Fetcher.ProductHunt.Scrape, agents...)
+		if err != nil {
+			log.Fatal("创建抓取器失败 %s: %v", constants.AgentPH, err)
+		}
+		err = rssHelper.AddFeed(constants.AgentPH, phFetcher, config.FeedConfig{
+			Dynamic:  cfg.Fetcher.ProductHunt.Dynamic.Compiled,
+			Sanitize: cfg.Fetcher.Sanitize,
 		})
+		if err != nil {
+			log.Fatal("添加源失败: %v", err)
+		}
 	}
 
 	// 添加 RSS 源
 	for _, rssCfg := range cfg.Fetcher.RSS {
 		if rssCfg.Enabled {
-			f := fetcher.NewRSSFetcher(nil)
-			if rssCfg.Send {
-				ag := agent.NewRSSFeishu(cfg.Feishu[constants.AgentTypeRSS])
-				switch rssCfg.Name {
-				case constants.AgentBestBlogs:
-					ag.SetFormatter(agent.BestBlogsFormatter)
+			agents := bindChannels(rssCfg.Name, rssCfg.Channels, rssCfg.Formatter, rssCfg.Card, rssCfg.Template)
+			var feedFetcher fetcher.FeedFetcher = fetcher.NewRSSFetcher(client, agents...)
+			if rssCfg.Scrape != nil {
+				if feedFetcher, err = fetcher.NewHTMLFetcher(client, *rssCfg.Scrape, agents...); err != nil {
+					log.Fatal("创建抓取器失败 %s: %v", rssCfg.Name, err)
 				}
-				f = fetcher.NewRSSFetcher(ag)
 			}
-
-			rssHelper.AddFeed(rssCfg.Name, f, config.FeedConfig{
-				URL: rssCfg.URL,
-			})
+			sanitizeCfg := cfg.Fetcher.Sanitize
+			if rssCfg.Sanitize != nil {
+				sanitizeCfg = *rssCfg.Sanitize
+			}
+			feedCfg := config.FeedConfig{
+				URL:      rssCfg.URL,
+				Interval: time.Duration(rssCfg.Interval) * time.Minute,
+				Cron:     rssCfg.Cron,
+				Jitter:   time.Duration(rssCfg.Jitter) * time.Second,
+				Sanitize: sanitizeCfg,
+				Filter:   rssCfg.Filter,
+			}
+			if rssCfg.Dynamic != nil {
+				feedCfg.Dynamic = rssCfg.Dynamic.Compiled
+			}
+			if err := rssHelper.AddFeed(rssCfg.Name, feedFetcher, feedCfg); err != nil {
+				log.Fatal("添加源失败: %v", err)
+			}
 		}
 	}
 
-	// 添加飞书代理
-	agentHelper.AddAgent(agent.AgentPHFeishu, phFeishu, cfg.Feishu[agent.AgentPHFeishu].Cron)
+	// 投递上次退出前未发送的消息
+	outbox.Deliver()
 
 	// 首次抓取和发送
 	log.Info("开始首次抓取...")
@@ -75,14 +206,24 @@ func main() {
 	// 启动抓取定时任务
 	go rssHelper.StartSchedule(cfg.Fetcher.Interval)
 
+	// 启动发件箱重试任务
+	go outbox.StartSchedule()
+
 	// 启动发送定时任务
 	if err := agentHelper.StartSchedule(); err != nil {
 		log.Fatal("启动发送定时任务失败: %v", err)
 	}
 
+	// 启动汇总定时任务
+	if err := digestHelper.StartSchedule(); err != nil {
+		log.Fatal("启动汇总定时任务失败: %v", err)
+	}
+
 	// 等待退出信号
 	<-sigChan
 	rssHelper.Stop()
+	outbox.Stop()
 	agentHelper.Stop()
+	digestHelper.Stop()
 	log.Info("程序已退出")
 }
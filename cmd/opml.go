@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/opml"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultSubscriptions 未配置 fetcher.subscriptions 时订阅文件的路径
+const defaultSubscriptions = "config/subscriptions.yaml"
+
+// subscription 导入时写入订阅文件的源，是 fetcher.rss 字段的子集
+type subscription struct {
+	Name     string   `yaml:"name"`
+	Title    string   `yaml:"title,omitempty"`
+	URL      string   `yaml:"url"`
+	Enabled  bool     `yaml:"enabled"`
+	Send     bool     `yaml:"send,omitempty"`
+	Channels []string `yaml:"channels,omitempty"`
+	Tags     []string `yaml:"tags,omitempty"`
+}
+
+// importOPML 将 OPML 中的订阅追加到订阅文件，已存在的地址跳过
+//
+//	import-opml [-out 订阅文件] [-enabled=false] [-send] [-channels a,b] <file.opml>
+func importOPML(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("import-opml", flag.ContinueOnError)
+	opts := addSubscriptionFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: import-opml [-out 订阅文件] [-enabled=false] [-send] [-channels a,b] <file.opml>")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	doc, err := opml.Parse(f)
+	if err != nil {
+		return err
+	}
+
+	added, skipped, err := opts.add(cfg, doc.Feeds())
+	if err != nil {
+		return err
+	}
+	log.Info("导入完成: 新增 %d 个源，跳过 %d 个已存在的源 -> %s", added, skipped, *opts.out)
+	opts.remind(cfg)
+	return nil
+}
+
+// subscriptionFlags import-opml 和 discover 共用的订阅选项
+type subscriptionFlags struct {
+	out      *string
+	enabled  *bool
+	send     *bool
+	channels *string
+}
+
+func addSubscriptionFlags(fs *flag.FlagSet, cfg *config.Config) *subscriptionFlags {
+	out := cfg.Fetcher.Subscriptions
+	if out == "" {
+		out = defaultSubscriptions
+	}
+	return &subscriptionFlags{
+		out:      fs.String("out", out, "订阅文件，默认为 fetcher.subscriptions 或 "+defaultSubscriptions),
+		enabled:  fs.Bool("enabled", true, "添加的源是否启用"),
+		send:     fs.Bool("send", false, "添加的源是否发送到默认的 rss 渠道"),
+		channels: fs.String("channels", "", "添加的源的发送渠道，逗号分隔，优先于 -send"),
+	}
+}
+
+// add 将源追加到订阅文件，地址已在配置或订阅文件中的源跳过
+func (o *subscriptionFlags) add(cfg *config.Config, feeds []opml.Feed) (added, skipped int, err error) {
+	var channels []string
+	for _, name := range strings.Split(*o.channels, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			if _, ok := cfg.Channel(name); !ok {
+				return 0, 0, fmt.Errorf("渠道不存在: %s", name)
+			}
+			channels = append(channels, name)
+		}
+	}
+
+	file, err := readSubscriptions(*o.out)
+	if err != nil {
+		return 0, 0, err
+	}
+	existing, err := file.entries()
+	if err != nil {
+		return 0, 0, err
+	}
+	names := make(map[string]bool)
+	urls := make(map[string]bool)
+	for _, rss := range cfg.Fetcher.RSS {
+		names[string(rss.Name)] = true
+		urls[rss.URL] = true
+	}
+	for _, item := range existing {
+		names[item.Name] = true
+		urls[item.URL] = true
+	}
+
+	for _, feed := range feeds {
+		if feed.XMLURL == "" || urls[feed.XMLURL] {
+			skipped++
+			continue
+		}
+		urls[feed.XMLURL] = true
+		name := feedName(feed.Title, feed.XMLURL, names)
+		names[name] = true
+		err := file.add(subscription{
+			Name:     name,
+			Title:    feed.Title,
+			URL:      feed.XMLURL,
+			Enabled:  *o.enabled,
+			Send:     *o.send && len(channels) == 0,
+			Channels: channels,
+			Tags:     feed.Tags,
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+		added++
+	}
+	if added == 0 {
+		return added, skipped, nil
+	}
+	return added, skipped, writeSubscriptions(*o.out, file)
+}
+
+// remind 订阅文件不是配置中的 fetcher.subscriptions 时提示修改配置
+func (o *subscriptionFlags) remind(cfg *config.Config) {
+	if cfg.Fetcher.Subscriptions != *o.out {
+		log.Info("请在配置中设置 fetcher.subscriptions: %s 以加载添加的源", *o.out)
+	}
+}
+
+// exportOPML 将 fetcher.rss 中的源导出为 OPML 2.0，动态地址和抓取页面的源不导出
+//
+//	export-opml [-out file.opml]
+func exportOPML(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("export-opml", flag.ContinueOnError)
+	out := fs.String("out", "", "输出文件，默认输出到标准输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var feeds []opml.Feed
+	for _, rss := range cfg.Fetcher.RSS {
+		if rss.Dynamic != nil || rss.Scrape != nil || rss.URL == "" {
+			log.Info("跳过不是固定订阅地址的源: %s", rss.Name)
+			continue
+		}
+		title := rss.Title
+		if title == "" {
+			title = string(rss.Name)
+		}
+		feeds = append(feeds, opml.Feed{Title: title, XMLURL: rss.URL, Tags: rss.Tags})
+	}
+	doc := opml.New("rss-agent subscriptions", feeds, time.Now())
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := doc.Write(w); err != nil {
+		return err
+	}
+	if *out != "" {
+		log.Info("已导出 %d 个源: %s", len(feeds), *out)
+	}
+	return nil
+}
+
+// subscriptionHeader 新建订阅文件时写在开头的注释
+const subscriptionHeader = "由 import-opml 和 discover 生成，字段与 fetcher.rss 相同，可以直接编辑"
+
+// subscriptionFile 订阅文件。以 yaml.Node 读写，只在 rss 列表末尾追加新的源，
+// 保留手动编辑的其他字段（filter、cron 等）和注释
+type subscriptionFile struct {
+	doc yaml.Node
+	rss *yaml.Node // rss 列表
+}
+
+func readSubscriptions(path string) (*subscriptionFile, error) {
+	file := &subscriptionFile{}
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取订阅文件失败 %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(content, &file.doc); err != nil {
+		return nil, fmt.Errorf("解析订阅文件失败 %s: %v", path, err)
+	}
+	if len(file.doc.Content) == 0 {
+		file.doc = yaml.Node{
+			Kind:        yaml.DocumentNode,
+			HeadComment: subscriptionHeader,
+			Content:     []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	root := file.doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("订阅文件格式错误 %s: 顶层应为映射", path)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "rss" {
+			file.rss = root.Content[i+1]
+		}
+	}
+	if file.rss == nil {
+		file.rss = &yaml.Node{}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "rss"}, file.rss)
+	}
+	// rss 为空时是 null 标量
+	if file.rss.Kind != yaml.SequenceNode {
+		if file.rss.Kind != 0 && file.rss.Tag != "!!null" {
+			return nil, fmt.Errorf("订阅文件格式错误 %s: rss 应为列表", path)
+		}
+		*file.rss = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", HeadComment: file.rss.HeadComment, LineComment: file.rss.LineComment}
+	}
+	return file, nil
+}
+
+// entries 订阅文件中已有的源，只解析名称和地址等基本字段
+func (f *subscriptionFile) entries() ([]subscription, error) {
+	var list []subscription
+	if err := f.rss.Decode(&list); err != nil {
+		return nil, fmt.Errorf("解析订阅文件中的源失败: %v", err)
+	}
+	return list, nil
+}
+
+// add 在 rss 列表末尾追加一个源
+func (f *subscriptionFile) add(sub subscription) error {
+	var node yaml.Node
+	if err := node.Encode(sub); err != nil {
+		return err
+	}
+	f.rss.Content = append(f.rss.Content, &node)
+	return nil
+}
+
+func writeSubscriptions(path string, file *subscriptionFile) error {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(&file.doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0644)
+}
+
+// feedName 由标题生成不重复且不是保留名称的源名称，标题为空时使用地址的域名
+func feedName(title, feedURL string, taken map[string]bool) string {
+	base := slug(title)
+	if base == "" {
+		if u, err := url.Parse(feedURL); err == nil {
+			base = slug(u.Hostname())
+		}
+	}
+	if base == "" {
+		base = "feed"
+	}
+	name := base
+	for i := 2; taken[name] || config.IsReservedFeedName(name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// slug 转换为小写，字母和数字以外的字符替换为连字符
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
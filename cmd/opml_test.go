@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/opml"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAddSubscriptionsKeepsFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subscriptions.yaml")
+	content := `# 手动维护的订阅
+rss:
+  - name: hn
+    url: https://hnrss.org/frontpage
+    enabled: true
+    cron: "0 9 * * *" # 每天9点
+    filter:
+      exclude:
+        - keywords: [crypto]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	enabled, send, channels := true, false, ""
+	opts := &subscriptionFlags{out: &path, enabled: &enabled, send: &send, channels: &channels}
+	added, skipped, err := opts.add(&config.Config{}, []opml.Feed{
+		{Title: "Hacker News", XMLURL: "https://hnrss.org/frontpage"},
+		{Title: "Go Blog", XMLURL: "https://go.dev/blog/feed.atom", Tags: []string{"Go"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 || skipped != 1 {
+		t.Fatalf("期望新增 1 个、跳过 1 个，实际 %d、%d", added, skipped)
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# 手动维护的订阅", "# 每天9点"} {
+		if !strings.Contains(string(written), want) {
+			t.Errorf("注释 %q 未保留:\n%s", want, written)
+		}
+	}
+	var raw struct {
+		RSS []map[string]interface{} `yaml:"rss"`
+	}
+	if err := yaml.Unmarshal(written, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.RSS) != 2 {
+		t.Fatalf("订阅文件中的源不符合预期:\n%s", written)
+	}
+	hn := raw.RSS[0]
+	if hn["cron"] != "0 9 * * *" || hn["filter"] == nil {
+		t.Fatalf("已有源的 cron 和 filter 未保留: %+v", hn)
+	}
+	if goBlog := raw.RSS[1]; goBlog["name"] != "go-blog" || goBlog["url"] != "https://go.dev/blog/feed.atom" {
+		t.Fatalf("新增的源不符合预期: %+v", goBlog)
+	}
+}
+
+func TestAddSubscriptionsNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "subscriptions.yaml")
+	enabled, send, channels := true, true, ""
+	opts := &subscriptionFlags{out: &path, enabled: &enabled, send: &send, channels: &channels}
+	if _, _, err := opts.add(&config.Config{}, []opml.Feed{{XMLURL: "https://go.dev/blog/feed.atom"}}); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(written), "# "+subscriptionHeader) {
+		t.Fatalf("新建的订阅文件缺少说明:\n%s", written)
+	}
+	file, err := readSubscriptions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := file.entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "go-dev" || !list[0].Send {
+		t.Fatalf("新增的源不符合预期: %+v", list)
+	}
+}
@@ -1,6 +1,10 @@
 package agent
 
-import "github.com/weirwei/rss-agent/internal/model"
+import (
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/render"
+)
 
 const (
 	AgentPHFeishu = "producthunt-daily"
@@ -11,3 +15,13 @@ type Agent interface {
 	Send(data model.FeedData) error
 	SetFormatter(formatter DataFormatter)
 }
+
+// CardSetter 支持按源配置消息卡片布局的代理，如飞书渠道
+type CardSetter interface {
+	SetCard(feed string, card config.CardConfig)
+}
+
+// TemplateSetter 支持按源配置条目消息模板的代理
+type TemplateSetter interface {
+	SetTemplate(feed string, tmpl *render.Template)
+}
@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+const (
+	dingTalkMaxBytes   = 20000 // markdown 消息长度上限
+	dingTalkRateLimit  = 20    // 每个机器人每分钟最多发送 20 条
+	dingTalkRateWindow = time.Minute
+)
+
+// DingTalkMessage 钉钉群机器人 markdown 消息
+type DingTalkMessage struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"markdown"`
+}
+
+// robotResponse 钉钉、企业微信机器人的响应
+type robotResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+type dingTalk struct {
+	client     *http.Client
+	webhookURL string
+	secret     string
+	keyword    string
+	length     int
+	formatter  DataFormatter
+	limiter    *rateLimiter
+	itemTemplates
+}
+
+func init() {
+	Register(constants.ChannelTypeDingTalk, func(cfg config.ChannelConfig, client *http.Client) (Agent, error) {
+		return NewDingTalk(cfg.AgentConfig, client), nil
+	})
+}
+
+// NewDingTalk 创建钉钉群机器人代理，配置了 Secret 时使用加签，配置了 Keyword 时确保消息包含关键词
+func NewDingTalk(config config.AgentConfig, client *http.Client) Agent {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &dingTalk{
+		client:     client,
+		webhookURL: config.WebhookURL,
+		secret:     config.Secret,
+		keyword:    config.Keyword,
+		length:     config.Length,
+		itemTemplates: itemTemplates{
+			tmpl: config.Template.Compiled,
+		},
+		limiter: newRateLimiter(dingTalkRateLimit, dingTalkRateWindow),
+	}
+}
+
+func (d *dingTalk) Send(data model.FeedData) error {
+	if d.formatter != nil {
+		d.formatter(&data)
+	}
+	if d.keyword != "" && !strings.Contains(data.Title, d.keyword) {
+		data.Title = d.keyword + " " + data.Title
+	}
+	items, err := markdownItems(data, d.length, d.template(data.Feed))
+	if err != nil {
+		return err
+	}
+	for _, text := range splitMarkdownItems(data.Title, items, dingTalkMaxBytes) {
+		var msg DingTalkMessage
+		msg.MsgType = "markdown"
+		msg.Markdown.Title = data.Title
+		msg.Markdown.Text = text
+		if err := d.post(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *dingTalk) SetFormatter(formatter DataFormatter) {
+	d.formatter = formatter
+}
+
+func (d *dingTalk) post(msg DingTalkMessage) error {
+	webhookURL, err := d.signedURL(time.Now())
+	if err != nil {
+		return err
+	}
+	jsonValue, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	d.limiter.Wait()
+	resp, err := d.client.Post(webhookURL, "application/json", bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return fmt.Errorf("failed to send message to DingTalk: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("dingtalk API error: %s", string(body))
+	}
+	var result robotResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("dingtalk API error: %s", string(body))
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("dingtalk API error: %d %s", result.ErrCode, result.ErrMsg)
+	}
+	log.Info("Message sent to DingTalk successfully. Title:%s", msg.Markdown.Title)
+	return nil
+}
+
+// signedURL 按钉钉加签规则在 webhook 地址上附加 timestamp 和 sign
+func (d *dingTalk) signedURL(now time.Time) (string, error) {
+	if d.secret == "" {
+		return d.webhookURL, nil
+	}
+	u, err := url.Parse(d.webhookURL)
+	if err != nil {
+		return "", fmt.Errorf("解析 webhook 地址失败: %v", err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(d.secret))
+	mac.Write([]byte(timestamp + "\n" + d.secret))
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/render"
+)
+
+func TestDingTalk(t *testing.T) {
+	const secret = "SECtest"
+	var received []DingTalkMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp := r.URL.Query().Get("timestamp")
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "\n" + secret))
+		if r.URL.Query().Get("sign") != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+			w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+			return
+		}
+		var msg DingTalkMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		if !strings.Contains(msg.Markdown.Text, "AI") {
+			w.Write([]byte(`{"errcode":310000,"errmsg":"keywords not in content"}`))
+			return
+		}
+		received = append(received, msg)
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer srv.Close()
+
+	data := model.FeedData{
+		Title: "Best Blogs",
+		Items: []model.FeedItem{{Title: "a", Link: "https://example.com/a", Summary: "summary"}},
+	}
+	ag := NewDingTalk(config.AgentConfig{WebhookURL: srv.URL + "?access_token=x", Secret: secret, Keyword: "AI", Length: 6}, srv.Client())
+	if err := ag.Send(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("期望发送 1 条，实际 %d", len(received))
+	}
+
+	// 未加签时应返回钉钉的错误
+	ag = NewDingTalk(config.AgentConfig{WebhookURL: srv.URL, Keyword: "AI", Length: 6}, srv.Client())
+	if err := ag.Send(data); err == nil || !strings.Contains(err.Error(), "310000") {
+		t.Fatalf("期望返回签名错误，实际 %v", err)
+	}
+}
+
+func TestSplitMarkdown(t *testing.T) {
+	data := model.FeedData{Title: "title"}
+	for i := 0; i < 10; i++ {
+		data.Items = append(data.Items, model.FeedItem{Title: "item", Summary: strings.Repeat("长", 200)})
+	}
+	data.Items = append(data.Items, model.FeedItem{Title: "huge", Summary: strings.Repeat("长", 5000)})
+
+	items, err := markdownItems(data, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := splitMarkdownItems(data.Title, items, weComMaxBytes)
+	if len(parts) < 2 {
+		t.Fatalf("期望拆分为多段，实际 %d", len(parts))
+	}
+	for _, part := range parts {
+		if len(part) > weComMaxBytes {
+			t.Fatalf("分段超过上限: %d", len(part))
+		}
+	}
+	if !strings.HasPrefix(parts[0], "### title (1/") {
+		t.Fatalf("分段标题不符合预期: %s", parts[0][:30])
+	}
+}
+
+func TestMarkdownItemsLength(t *testing.T) {
+	data := model.FeedData{Title: "title"}
+	for i := 0; i < 3; i++ {
+		data.Items = append(data.Items, model.FeedItem{Title: fmt.Sprintf("item-%d", i)})
+	}
+	tmpl, err := render.Parse("item", "{{.Title}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 默认布局和模板对 length 的处理一致：0 表示不限制
+	for _, c := range []struct{ length, want int }{{0, 3}, {2, 2}, {5, 3}} {
+		for _, tp := range []*render.Template{nil, tmpl} {
+			items, err := markdownItems(data, c.length, tp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != c.want {
+				t.Errorf("length %d 模板 %v: 期望 %d 条，实际 %d", c.length, tp != nil, c.want, len(items))
+			}
+		}
+	}
+}
+
+func TestMarkdownItemWithoutLink(t *testing.T) {
+	if got := markdownItem(model.FeedItem{Title: "no link"}); !strings.HasPrefix(got, "#### no link\n") {
+		t.Fatalf("没有链接的条目不符合预期: %q", got)
+	}
+}
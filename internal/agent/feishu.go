@@ -2,10 +2,15 @@ package agent
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/weirwei/rss-agent/internal/log"
 )
@@ -23,8 +28,10 @@ type AElement struct {
 
 // FeishuMessage represents the message structure for Feishu
 type FeishuMessage struct {
-	MsgType string `json:"msg_type"`
-	Content struct {
+	Timestamp string `json:"timestamp,omitempty"`
+	Sign      string `json:"sign,omitempty"`
+	MsgType   string `json:"msg_type"`
+	Content   struct {
 		Post struct {
 			ZhCn struct {
 				Title   string          `json:"title"`
@@ -34,8 +41,24 @@ type FeishuMessage struct {
 	} `json:"content"`
 }
 
-// SendToFeishu sends a message to the Feishu robot
-func SendToFeishu(feishuWebhookURL string, title string, content [][]interface{}) error {
+// feishuResponse is the response of the Feishu robot. Errors such as a
+// signature mismatch are returned with HTTP 200 and a non-zero code.
+type feishuResponse struct {
+	Code          int    `json:"code"`
+	Msg           string `json:"msg"`
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
+}
+
+// feishuSign signs the message with the robot secret
+func feishuSign(secret string, timestamp int64) string {
+	stringToSign := fmt.Sprintf("%d\n%s", timestamp, secret)
+	mac := hmac.New(sha256.New, []byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SendToFeishu sends a message to the Feishu robot, signing it when secret is set
+func SendToFeishu(client *http.Client, feishuWebhookURL string, secret string, title string, content [][]interface{}) error {
 	msg := FeishuMessage{
 		MsgType: "post",
 		Content: struct {
@@ -62,17 +85,45 @@ func SendToFeishu(feishuWebhookURL string, title string, content [][]interface{}
 			},
 		},
 	}
+	msg.Timestamp, msg.Sign = feishuSignature(secret)
+	return postToFeishu(client, feishuWebhookURL, title, msg)
+}
+
+// feishuSignature returns the timestamp and sign fields, both empty when secret is not set
+func feishuSignature(secret string) (string, string) {
+	if secret == "" {
+		return "", ""
+	}
+	timestamp := time.Now().Unix()
+	return strconv.FormatInt(timestamp, 10), feishuSign(secret, timestamp)
+}
+
+// postToFeishu posts a message to the Feishu robot and checks the response
+func postToFeishu(client *http.Client, feishuWebhookURL string, title string, msg interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
 	jsonValue, _ := json.Marshal(msg)
-	resp, err := http.Post(feishuWebhookURL, "application/json", bytes.NewBuffer(jsonValue))
+	resp, err := client.Post(feishuWebhookURL, "application/json", bytes.NewBuffer(jsonValue))
 	if err != nil {
 		return fmt.Errorf("failed to send message to Feishu: %v", err)
 	}
 	defer resp.Body.Close()
 
+	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("feishu API error: %s", string(body))
 	}
+	var result feishuResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("feishu API error: %s", string(body))
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("feishu API error: %d %s", result.Code, result.Msg)
+	}
+	if result.StatusCode != 0 {
+		return fmt.Errorf("feishu API error: %d %s", result.StatusCode, result.StatusMessage)
+	}
 	log.Info("Message sent to Feishu successfully. Title:%s", title)
 	return nil
 }
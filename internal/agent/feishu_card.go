@@ -0,0 +1,258 @@
+package agent
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/render"
+)
+
+const defaultCardTemplate = "blue"
+
+// FeishuCardMessage 飞书交互式卡片消息
+type FeishuCardMessage struct {
+	Timestamp string     `json:"timestamp,omitempty"`
+	Sign      string     `json:"sign,omitempty"`
+	MsgType   string     `json:"msg_type"`
+	Card      FeishuCard `json:"card"`
+}
+
+// FeishuCard 飞书消息卡片
+type FeishuCard struct {
+	Config   FeishuCardConfig    `json:"config"`
+	Header   FeishuCardHeader    `json:"header"`
+	Elements []FeishuCardElement `json:"elements"`
+}
+
+// FeishuCardConfig 卡片属性
+type FeishuCardConfig struct {
+	WideScreenMode bool `json:"wide_screen_mode"`
+}
+
+// FeishuCardHeader 卡片标题栏
+type FeishuCardHeader struct {
+	Title    FeishuCardText `json:"title"`
+	Template string         `json:"template,omitempty"`
+}
+
+// FeishuCardText 卡片中的文本对象，Tag 为 plain_text 或 lark_md
+type FeishuCardText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+// FeishuCardElement 卡片内容元素：div、hr、note、img、action
+type FeishuCardElement struct {
+	Tag      string             `json:"tag"`
+	Text     *FeishuCardText    `json:"text,omitempty"`
+	Elements []FeishuCardText   `json:"elements,omitempty"`
+	ImgKey   string             `json:"img_key,omitempty"`
+	Alt      *FeishuCardText    `json:"alt,omitempty"`
+	Actions  []FeishuCardButton `json:"actions,omitempty"`
+}
+
+// FeishuCardButton 跳转链接按钮
+type FeishuCardButton struct {
+	Tag  string         `json:"tag"`
+	Text FeishuCardText `json:"text"`
+	Type string         `json:"type"`
+	URL  string         `json:"url"`
+}
+
+// SendCardToFeishu 发送交互式卡片消息，配置了 secret 时签名
+func SendCardToFeishu(client *http.Client, feishuWebhookURL string, secret string, card FeishuCard) error {
+	msg := FeishuCardMessage{
+		MsgType: "interactive",
+		Card:    card,
+	}
+	msg.Timestamp, msg.Sign = feishuSignature(secret)
+	return postToFeishu(client, feishuWebhookURL, card.Header.Title.Content, msg)
+}
+
+// feishuLayouts 飞书渠道的消息布局，源可以覆盖渠道的默认布局
+type feishuLayouts struct {
+	card  config.CardConfig
+	feeds map[string]config.CardConfig
+}
+
+// SetCard 设置指定源的卡片布局
+func (l *feishuLayouts) SetCard(feed string, card config.CardConfig) {
+	if l.feeds == nil {
+		l.feeds = make(map[string]config.CardConfig)
+	}
+	l.feeds[feed] = card
+}
+
+// layout 返回源使用的卡片布局
+func (l *feishuLayouts) layout(feed string) config.CardConfig {
+	if card, ok := l.feeds[feed]; ok {
+		return card
+	}
+	return l.card
+}
+
+// useCard 源是否以交互式卡片发送，未配置时使用富文本
+func (l *feishuLayouts) useCard(feed string) bool {
+	return l.layout(feed).Format == constants.FeishuFormatCard
+}
+
+// renderFeishuCards 将数据渲染为卡片：每个条目包含标题链接、摘要、作者和发布时间、图片及按钮，
+// 配置了模板时标题、摘要和备注由模板渲染。序列化后超过 maxBytes 时按条目拆分为多张
+func renderFeishuCards(data model.FeedData, length int, layout config.CardConfig, tmpl *render.Template, maxBytes int) ([]FeishuCard, error) {
+	template := layout.Template
+	if template == "" {
+		template = defaultCardTemplate
+	}
+	card := FeishuCard{
+		Config: FeishuCardConfig{WideScreenMode: true},
+		Header: FeishuCardHeader{
+			Title:    FeishuCardText{Tag: "plain_text", Content: data.Title},
+			Template: template,
+		},
+	}
+	var texts []string
+	if tmpl != nil {
+		var err error
+		if texts, err = renderItems(tmpl, data, length); err != nil {
+			return nil, err
+		}
+	}
+	var items [][]FeishuCardElement
+	for i, item := range data.Items {
+		if length > 0 && i >= length {
+			break
+		}
+		if texts != nil {
+			items = append(items, feishuCardItem(item, layout, texts[i]))
+		} else {
+			items = append(items, feishuCardItem(item, layout, ""))
+		}
+	}
+	return splitFeishuCard(card, items, maxBytes), nil
+}
+
+// sendFeishuCards 依次发送拆分后的卡片
+func sendFeishuCards(client *http.Client, feishuWebhookURL string, secret string, cards []FeishuCard) error {
+	for _, card := range cards {
+		if err := SendCardToFeishu(client, feishuWebhookURL, secret, card); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templatePostContent 用模板渲染富文本内容，模板中的 markdown 链接转换为超链接，
+// 富文本的 text 元素不支持加粗，去掉 ** 标记
+func templatePostContent(tmpl *render.Template, data model.FeedData, length int) ([][]interface{}, error) {
+	texts, err := renderItems(tmpl, data, length)
+	if err != nil {
+		return nil, err
+	}
+	var content [][]interface{}
+	for _, text := range texts {
+		row := []interface{}{TextElement{Tag: "text", Text: "\n"}}
+		for _, seg := range render.Segments(text) {
+			if seg.URL != "" {
+				row = append(row, AElement{Tag: "a", Text: seg.Text, Href: seg.URL})
+			} else {
+				row = append(row, TextElement{Tag: "text", Text: strings.ReplaceAll(seg.Text, "**", "")})
+			}
+		}
+		row = append(row, TextElement{Tag: "text", Text: "\n"})
+		content = append(content, row)
+	}
+	return content, nil
+}
+
+// sendFeishuPosts 按大小拆分富文本内容后依次发送
+func sendFeishuPosts(client *http.Client, feishuWebhookURL string, secret string, title string, content [][]interface{}) error {
+	for _, part := range splitFeishuPost(title, content, feishuMaxBytes) {
+		if err := SendToFeishu(client, feishuWebhookURL, secret, part.title, part.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// feishuCardItem 渲染单个条目，text 不为空时为模板渲染的内容，替代标题、摘要和备注。
+// 卡片的 img 元素只接受上传到飞书的 img_key，因此 Image 为 img_key 时展示图片，
+// 为普通链接时在备注中附上图片链接
+func feishuCardItem(item model.FeedItem, layout config.CardConfig, text string) []FeishuCardElement {
+	templated := text != ""
+	if !templated {
+		text = "**" + larkMarkdownLink(item.Title, item.Link) + "**"
+		summary := item.Summary
+		if summary == "" {
+			summary = item.Description
+		}
+		if !layout.HideSummary && summary != "" {
+			text += "\n" + summary
+		}
+	}
+	elements := []FeishuCardElement{{
+		Tag:  "div",
+		Text: &FeishuCardText{Tag: "lark_md", Content: text},
+	}}
+
+	image := ""
+	if !layout.HideImage {
+		image = item.Image
+	}
+	if strings.HasPrefix(image, "img_") {
+		elements = append(elements, FeishuCardElement{
+			Tag:    "img",
+			ImgKey: image,
+			Alt:    &FeishuCardText{Tag: "plain_text", Content: item.Title},
+		})
+		image = ""
+	}
+
+	if !layout.HideNote && !templated {
+		var note []FeishuCardText
+		if item.Author != "" {
+			note = append(note, FeishuCardText{Tag: "plain_text", Content: "作者：" + item.Author})
+		}
+		if !item.Published.IsZero() {
+			note = append(note, FeishuCardText{Tag: "plain_text", Content: "发布时间：" + item.Published.Format(time.DateTime)})
+		}
+		if image != "" {
+			note = append(note, FeishuCardText{Tag: "lark_md", Content: larkMarkdownLink("图片", image)})
+		}
+		if len(note) > 0 {
+			elements = append(elements, FeishuCardElement{Tag: "note", Elements: note})
+		}
+	}
+
+	if !layout.HideButtons && item.Link != "" {
+		buttons := []FeishuCardButton{{
+			Tag:  "button",
+			Text: FeishuCardText{Tag: "plain_text", Content: "打开"},
+			Type: "primary",
+			URL:  item.Link,
+		}}
+		if layout.ReadLaterURL != "" {
+			buttons = append(buttons, FeishuCardButton{
+				Tag:  "button",
+				Text: FeishuCardText{Tag: "plain_text", Content: "稍后阅读"},
+				Type: "default",
+				URL:  strings.ReplaceAll(layout.ReadLaterURL, "{{url}}", url.QueryEscape(item.Link)),
+			})
+		}
+		elements = append(elements, FeishuCardElement{Tag: "action", Actions: buttons})
+	}
+	return elements
+}
+
+// larkMarkdownLink 生成 lark_md 链接，链接文本中的方括号会破坏语法，替换为全角字符
+func larkMarkdownLink(text, link string) string {
+	text = strings.NewReplacer("[", "［", "]", "］").Replace(text)
+	if link == "" {
+		return text
+	}
+	return "[" + text + "](" + link + ")"
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestFeishuCard(t *testing.T) {
+	var messages []map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		messages = append(messages, msg)
+		w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer srv.Close()
+
+	data := model.FeedData{
+		Feed:  "best-blogs",
+		Title: "Best Blogs",
+		Items: []model.FeedItem{
+			{Title: "a [b]", Link: "https://example.com/1", Summary: "summary", Author: "alice", Published: time.Now(), Image: "img_v2_xxx"},
+			{Title: "c", Link: "https://example.com/2", Description: "description", Image: "https://example.com/2.png"},
+		},
+	}
+	ag := NewRSSFeishu(config.AgentConfig{WebhookURL: srv.URL, Length: 6}, srv.Client())
+
+	// 未配置卡片布局时使用富文本
+	if err := ag.Send(data); err != nil {
+		t.Fatal(err)
+	}
+	ag.(CardSetter).SetCard("best-blogs", config.CardConfig{
+		Format:       constants.FeishuFormatCard,
+		ReadLaterURL: "https://later.example.com/save?url={{url}}",
+	})
+	if err := ag.Send(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("期望发送 2 条消息，实际 %d", len(messages))
+	}
+	if got := string(messages[0]["msg_type"]); got != `"post"` {
+		t.Fatalf("期望富文本消息，实际 %s", got)
+	}
+	if got := string(messages[1]["msg_type"]); got != `"interactive"` {
+		t.Fatalf("期望卡片消息，实际 %s", got)
+	}
+
+	var card FeishuCard
+	if err := json.Unmarshal(messages[1]["card"], &card); err != nil {
+		t.Fatal(err)
+	}
+	if card.Header.Title.Content != "Best Blogs" || card.Header.Template != defaultCardTemplate {
+		t.Fatalf("卡片标题不符合预期: %+v", card.Header)
+	}
+	var tags []string
+	for _, e := range card.Elements {
+		tags = append(tags, e.Tag)
+	}
+	want := []string{"div", "img", "note", "action", "hr", "div", "note", "action"}
+	if len(tags) != len(want) {
+		t.Fatalf("期望元素 %v，实际 %v", want, tags)
+	}
+	for i := range want {
+		if tags[i] != want[i] {
+			t.Fatalf("期望元素 %v，实际 %v", want, tags)
+		}
+	}
+	if got := card.Elements[0].Text.Content; got != "**[a ［b］](https://example.com/1)**\nsummary" {
+		t.Fatalf("标题链接不符合预期: %s", got)
+	}
+	if got := card.Elements[5].Text.Content; got != "**[c](https://example.com/2)**\ndescription" {
+		t.Fatalf("摘要应回退到描述: %s", got)
+	}
+	// 图片不是 img_key 时在备注中附上链接
+	if note := card.Elements[6].Elements; len(note) != 1 || note[0].Content != "[图片](https://example.com/2.png)" {
+		t.Fatalf("备注不符合预期: %+v", note)
+	}
+	actions := card.Elements[3].Actions
+	if len(actions) != 2 || actions[1].URL != "https://later.example.com/save?url=https%3A%2F%2Fexample.com%2F1" {
+		t.Fatalf("按钮不符合预期: %+v", actions)
+	}
+}
@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	feishuMaxBytes    = 20 * 1024 // 自定义机器人请求体大小上限
+	feishuSignReserve = 128       // 为签名字段预留的字节数
+	feishuPartReserve = len(" (99/99)")
+)
+
+// feishuPostPart 拆分后的一条富文本消息
+type feishuPostPart struct {
+	title   string
+	content [][]interface{}
+}
+
+// splitFeishuPost 将富文本内容按条目边界拆分为多条，每条序列化后不超过 maxBytes 字节，
+// 单个条目本身超限时截断。多于一条时在标题后标注序号
+func splitFeishuPost(title string, content [][]interface{}, maxBytes int) []feishuPostPart {
+	var empty FeishuMessage
+	empty.MsgType = "post"
+	empty.Content.Post.ZhCn.Title = title
+	overhead := jsonSize(empty) + feishuSignReserve + feishuPartReserve
+
+	sizes := make([]int, len(content))
+	for i := range content {
+		content[i] = fitFeishuRow(content[i], maxBytes-overhead)
+		sizes[i] = jsonSize(content[i]) + 1
+	}
+	var parts []feishuPostPart
+	for _, r := range splitBySize(sizes, overhead, maxBytes) {
+		parts = append(parts, feishuPostPart{content: content[r[0]:r[1]]})
+	}
+	for i := range parts {
+		parts[i].title = numberedTitle(title, i, len(parts))
+	}
+	return parts
+}
+
+// splitFeishuCard 将卡片按条目边界拆分为多张，items 为每个条目的元素，条目之间以分割线隔开
+func splitFeishuCard(card FeishuCard, items [][]FeishuCardElement, maxBytes int) []FeishuCard {
+	title := card.Header.Title.Content
+	overhead := jsonSize(FeishuCardMessage{MsgType: "interactive", Card: card}) + feishuSignReserve + feishuPartReserve
+	hr := jsonSize(FeishuCardElement{Tag: "hr"}) + 1
+
+	sizes := make([]int, len(items))
+	for i := range items {
+		items[i] = fitFeishuCardItem(items[i], maxBytes-overhead-hr)
+		sizes[i] = jsonSize(items[i]) + hr
+	}
+	var cards []FeishuCard
+	for _, r := range splitBySize(sizes, overhead, maxBytes) {
+		part := card
+		part.Elements = nil
+		for i, elements := range items[r[0]:r[1]] {
+			if i > 0 {
+				part.Elements = append(part.Elements, FeishuCardElement{Tag: "hr"})
+			}
+			part.Elements = append(part.Elements, elements...)
+		}
+		cards = append(cards, part)
+	}
+	for i := range cards {
+		cards[i].Header.Title.Content = numberedTitle(title, i, len(cards))
+	}
+	return cards
+}
+
+// splitBySize 按条目边界分组，每组条目大小之和加上 overhead 不超过 limit，返回每组的 [起, 止) 下标
+func splitBySize(sizes []int, overhead, limit int) [][2]int {
+	var ranges [][2]int
+	start, total := 0, overhead
+	for i, size := range sizes {
+		if i > start && total+size > limit {
+			ranges = append(ranges, [2]int{start, i})
+			start, total = i, overhead
+		}
+		total += size
+	}
+	if start < len(sizes) {
+		ranges = append(ranges, [2]int{start, len(sizes)})
+	}
+	return ranges
+}
+
+// fitFeishuRow 截断富文本条目中最长的文本，直到序列化后不超过 limit 字节
+func fitFeishuRow(row []interface{}, limit int) []interface{} {
+	for excess := jsonSize(row) - limit; excess > 0; excess = jsonSize(row) - limit {
+		longest := -1
+		for i, e := range row {
+			if t, ok := e.(TextElement); ok && (longest < 0 || len(t.Text) > len(row[longest].(TextElement).Text)) {
+				longest = i
+			}
+		}
+		if longest < 0 {
+			break
+		}
+		t := row[longest].(TextElement)
+		if len(t.Text) <= len("…\n\n") {
+			break
+		}
+		t.Text = truncateBytes(t.Text, max(len(t.Text)-excess, len("…\n\n")))
+		row[longest] = t
+	}
+	return row
+}
+
+// fitFeishuCardItem 截断卡片条目中最长的文本，直到序列化后不超过 limit 字节
+func fitFeishuCardItem(elements []FeishuCardElement, limit int) []FeishuCardElement {
+	for excess := jsonSize(elements) - limit; excess > 0; excess = jsonSize(elements) - limit {
+		longest := -1
+		for i, e := range elements {
+			if e.Text != nil && (longest < 0 || len(e.Text.Content) > len(elements[longest].Text.Content)) {
+				longest = i
+			}
+		}
+		if longest < 0 || len(elements[longest].Text.Content) <= len("…\n\n") {
+			break
+		}
+		text := *elements[longest].Text
+		text.Content = truncateBytes(text.Content, max(len(text.Content)-excess, len("…\n\n")))
+		elements[longest].Text = &text
+	}
+	return elements
+}
+
+// numberedTitle 拆分为多条时在标题后标注序号
+func numberedTitle(title string, i, n int) string {
+	if n <= 1 {
+		return title
+	}
+	return fmt.Sprintf("%s (%d/%d)", title, i+1, n)
+}
+
+func jsonSize(v interface{}) int {
+	b, _ := json.Marshal(v)
+	return len(b)
+}
@@ -0,0 +1,86 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestFeishuSplit(t *testing.T) {
+	for _, format := range []string{constants.FeishuFormatPost, constants.FeishuFormatCard} {
+		t.Run(format, func(t *testing.T) {
+			var titles []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if len(body) > feishuMaxBytes {
+					t.Errorf("消息大小 %d 超过上限", len(body))
+				}
+				var msg struct {
+					Content struct {
+						Post struct {
+							ZhCn struct {
+								Title string `json:"title"`
+							} `json:"zh_cn"`
+						} `json:"post"`
+					} `json:"content"`
+					Card FeishuCard `json:"card"`
+				}
+				if err := json.Unmarshal(body, &msg); err != nil {
+					t.Error(err)
+				}
+				titles = append(titles, msg.Content.Post.ZhCn.Title+msg.Card.Header.Title.Content)
+				w.Write([]byte(`{"code":0,"msg":"success"}`))
+			}))
+			defer srv.Close()
+
+			// 每条约 6KB，其中一条 60KB 需要截断
+			data := model.FeedData{Feed: "blogs", Title: "Blogs"}
+			for i := 0; i < 6; i++ {
+				size := 6 * 1024
+				if i == 2 {
+					size = 60 * 1024
+				}
+				data.Items = append(data.Items, model.FeedItem{
+					Title:       fmt.Sprintf("item %d", i),
+					Link:        fmt.Sprintf("https://example.com/%d", i),
+					Description: strings.Repeat("<p>正文</p>", size/len("<p>正文</p>")),
+					Published:   time.Now(),
+				})
+			}
+			ag := NewRSSFeishu(config.AgentConfig{
+				WebhookURL: srv.URL,
+				Secret:     "secret",
+				Length:     10,
+				Card:       config.CardConfig{Format: format},
+			}, srv.Client())
+			if err := ag.Send(data); err != nil {
+				t.Fatal(err)
+			}
+			if len(titles) < 3 {
+				t.Fatalf("期望拆分为多条消息，实际 %d", len(titles))
+			}
+			for i, title := range titles {
+				if want := fmt.Sprintf("Blogs (%d/%d)", i+1, len(titles)); title != want {
+					t.Fatalf("期望标题 %s，实际 %s", want, title)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitBySize(t *testing.T) {
+	got := splitBySize([]int{3, 3, 5, 1, 9}, 2, 10)
+	want := [][2]int{{0, 2}, {2, 4}, {4, 5}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("期望 %v，实际 %v", want, got)
+	}
+}
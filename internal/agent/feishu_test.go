@@ -2,7 +2,13 @@ package agent
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/weirwei/ikit/iutil"
@@ -54,3 +60,31 @@ func TestFeishu(t *testing.T) {
 	}
 	t.Log(iutil.ToJson(resp))
 }
+
+func TestSendToFeishuSign(t *testing.T) {
+	// 飞书文档的签名算法：以 timestamp + "\n" + 密钥为 HMAC-SHA256 的密钥，对空字符串签名后 Base64 编码
+	if got, want := feishuSign("secret", 1599360473), "q4jswNiMy51J5JuQV566yJat0/lQ/c+22kINzUgKsGU="; got != want {
+		t.Fatalf("签名不符合预期: 期望 %s，实际 %s", want, got)
+	}
+
+	const secret = "secret"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg FeishuMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		mac := hmac.New(sha256.New, []byte(msg.Timestamp+"\n"+secret))
+		if msg.Sign == "" || msg.Sign != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+			w.Write([]byte(`{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`))
+			return
+		}
+		w.Write([]byte(`{"code":0,"msg":"success","data":{}}`))
+	}))
+	defer srv.Close()
+
+	if err := SendToFeishu(srv.Client(), srv.URL, secret, "title", nil); err != nil {
+		t.Fatalf("签名消息应发送成功: %v", err)
+	}
+	err := SendToFeishu(srv.Client(), srv.URL, "", "title", nil)
+	if err == nil || !strings.Contains(err.Error(), "19021") {
+		t.Fatalf("期望返回签名校验失败，实际 %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/render"
+)
+
+// markdownItem 将条目渲染为钉钉、企业微信通用的 markdown 片段
+func markdownItem(item model.FeedItem) string {
+	var b strings.Builder
+	if item.Link == "" {
+		b.WriteString("#### " + item.Title + "\n")
+	} else {
+		fmt.Fprintf(&b, "#### [%s](%s)\n", item.Title, item.Link)
+	}
+	summary := item.Summary
+	if summary == "" {
+		summary = item.Description
+	}
+	if summary != "" {
+		b.WriteString(summary + "\n")
+	}
+	b.WriteString("> 发布时间：" + item.Published.Format(time.DateTime) + "\n\n")
+	return b.String()
+}
+
+// markdownItems 将前 length 个条目渲染为 markdown 片段，length 为 0 时不限制，配置了模板时使用模板渲染
+func markdownItems(data model.FeedData, length int, tmpl *render.Template) ([]string, error) {
+	if tmpl != nil {
+		texts, err := renderItems(tmpl, data, length)
+		if err != nil {
+			return nil, err
+		}
+		for i := range texts {
+			texts[i] += "\n\n"
+		}
+		return texts, nil
+	}
+	var items []string
+	for i, item := range data.Items {
+		if length > 0 && i >= length {
+			break
+		}
+		items = append(items, markdownItem(item))
+	}
+	return items, nil
+}
+
+// splitMarkdownItems 将渲染好的条目按边界拼接为多段 markdown，每段不超过 maxBytes 字节，
+// 单个条目本身超限时截断。多于一段时在标题后标注序号
+func splitMarkdownItems(title string, items []string, maxBytes int) []string {
+	var parts []string
+	header := func() string { return "### " + title + "\n\n" }
+	// 为序号预留空间
+	limit := maxBytes - len(header()) - len(" (99/99)")
+
+	var current strings.Builder
+	for _, item := range items {
+		text := truncateBytes(item, limit)
+		if current.Len() > 0 && current.Len()+len(text) > limit {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+		current.WriteString(text)
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+
+	for i := range parts {
+		parts[i] = "### " + numberedTitle(title, i, len(parts)) + "\n\n" + parts[i]
+	}
+	return parts
+}
+
+// truncateBytes 按字节上限截断文本，不拆分多字节字符
+func truncateBytes(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	const suffix = "…\n\n"
+	cut := limit - len(suffix)
+	for cut > 0 && !isRuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + suffix
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// rateLimiter 滑动窗口限流，窗口内最多发送 limit 条
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   []time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window}
+}
+
+// Wait 阻塞直到可以发送下一条，等待期间不持有锁
+func (r *rateLimiter) Wait() {
+	for {
+		r.mu.Lock()
+		now := time.Now()
+		for len(r.sent) > 0 && now.Sub(r.sent[0]) >= r.window {
+			r.sent = r.sent[1:]
+		}
+		if len(r.sent) < r.limit {
+			r.sent = append(r.sent, now)
+			r.mu.Unlock()
+			return
+		}
+		wait := r.window - now.Sub(r.sent[0])
+		r.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
@@ -1,28 +1,68 @@
 package agent
 
 import (
+	"net/http"
+
 	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
 	"github.com/weirwei/rss-agent/internal/model"
 )
 
 type phFeishu struct {
+	client     *http.Client
 	webhookURL string
+	secret     string
 	length     int
+	feishuLayouts
+	itemTemplates
+}
+
+func init() {
+	Register(constants.ChannelTypeFeishuPH, func(cfg config.ChannelConfig, client *http.Client) (Agent, error) {
+		return NewPHFeishu(cfg.AgentConfig, client), nil
+	})
 }
 
-func NewPHFeishu(config config.AgentConfig) Agent {
+func NewPHFeishu(config config.AgentConfig, client *http.Client) Agent {
 	return &phFeishu{
+		client:     client,
 		webhookURL: config.WebhookURL,
+		secret:     config.Secret,
 		length:     config.Length,
+		feishuLayouts: feishuLayouts{
+			card: config.Card,
+		},
+		itemTemplates: itemTemplates{
+			tmpl: config.Template.Compiled,
+		},
 	}
 }
 
 func (p *phFeishu) Send(data model.FeedData) error {
-	content, err := p.formatToMarkdown(data)
+	tmpl := p.template(data.Feed)
+	if p.useCard(data.Feed) {
+		// 模板直接使用原始条目，默认布局将标语合并到标题中
+		cardData := data
+		if tmpl == nil {
+			cardData = phCardData(data)
+		}
+		cards, err := renderFeishuCards(cardData, p.length, p.layout(data.Feed), tmpl, feishuMaxBytes)
+		if err != nil {
+			return err
+		}
+		return sendFeishuCards(p.client, p.webhookURL, p.secret, cards)
+	}
+	var content [][]interface{}
+	var err error
+	if tmpl != nil {
+		content, err = templatePostContent(tmpl, data, p.length)
+	} else {
+		content, err = p.formatToMarkdown(data)
+	}
 	if err != nil {
 		return err
 	}
-	return SendToFeishu(p.webhookURL, data.Title, content)
+	return sendFeishuPosts(p.client, p.webhookURL, p.secret, data.Title, content)
 }
 
 func (p *phFeishu) SetFormatter(formatter DataFormatter) {
@@ -31,7 +71,7 @@ func (p *phFeishu) SetFormatter(formatter DataFormatter) {
 func (p *phFeishu) formatToMarkdown(data model.FeedData) ([][]interface{}, error) {
 	var content [][]interface{}
 	for i, item := range data.Items {
-		if i >= p.length {
+		if p.length > 0 && i >= p.length {
 			break
 		}
 		var row []interface{}
@@ -63,3 +103,15 @@ func (p *phFeishu) formatToMarkdown(data model.FeedData) ([][]interface{}, error
 
 	return content, nil
 }
+
+// phCardData 卡片标题展示产品名和标语，摘要展示详细描述
+func phCardData(data model.FeedData) model.FeedData {
+	items := make([]model.FeedItem, len(data.Items))
+	for i, item := range data.Items {
+		item.Title = item.Title + ": " + item.Summary
+		item.Summary = item.Description
+		items[i] = item
+	}
+	data.Items = items
+	return data
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+// Factory 根据渠道配置创建代理
+type Factory func(cfg config.ChannelConfig, client *http.Client) (Agent, error)
+
+var (
+	factories  = make(map[string]Factory)
+	formatters = make(map[string]DataFormatter)
+)
+
+// Register 注册渠道类型，通常在各渠道实现的 init 中调用
+func Register(channelType string, factory Factory) {
+	if _, ok := factories[channelType]; ok {
+		panic(fmt.Sprintf("渠道类型重复注册: %s", channelType))
+	}
+	factories[channelType] = factory
+}
+
+// New 根据渠道配置创建代理
+func New(cfg config.ChannelConfig, client *http.Client) (Agent, error) {
+	factory, ok := factories[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("未知的渠道类型 %s: %s", cfg.Name, cfg.Type)
+	}
+	return factory(cfg, client)
+}
+
+// NewChannels 创建所有渠道，返回渠道名称到代理的映射
+func NewChannels(cfgs []config.ChannelConfig, client *http.Client) (map[string]Agent, error) {
+	channels := make(map[string]Agent, len(cfgs))
+	for _, cfg := range cfgs {
+		if _, ok := channels[cfg.Name]; ok {
+			return nil, fmt.Errorf("渠道名称重复: %s", cfg.Name)
+		}
+		ag, err := New(cfg, client)
+		if err != nil {
+			return nil, err
+		}
+		channels[cfg.Name] = ag
+	}
+	return channels, nil
+}
+
+// RegisterFormatter 注册命名的数据格式化器，供源配置引用
+func RegisterFormatter(name string, formatter DataFormatter) {
+	formatters[name] = formatter
+}
+
+// GetFormatter 按名称查找数据格式化器
+func GetFormatter(name string) (DataFormatter, bool) {
+	formatter, ok := formatters[name]
+	return formatter, ok
+}
+
+// WithFormatter 返回发送前先执行 formatter 的代理，不修改共享的原代理
+func WithFormatter(ag Agent, formatter DataFormatter) Agent {
+	if formatter == nil {
+		return ag
+	}
+	return &formattedAgent{Agent: ag, formatter: formatter}
+}
+
+type formattedAgent struct {
+	Agent
+	formatter DataFormatter
+}
+
+func (f *formattedAgent) Send(data model.FeedData) error {
+	f.formatter(&data)
+	return f.Agent.Send(data)
+}
+
+func (f *formattedAgent) SetFormatter(formatter DataFormatter) {
+	f.formatter = formatter
+}
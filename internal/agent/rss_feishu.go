@@ -1,25 +1,46 @@
 package agent
 
 import (
+	"net/http"
 	"regexp"
 	"time"
 
 	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
 	"github.com/weirwei/rss-agent/internal/model"
 )
 
 type rssFeishu struct {
+	client     *http.Client
 	webhookURL string
+	secret     string
 	length     int
 	formatter  DataFormatter
+	feishuLayouts
+	itemTemplates
 }
 
 type DataFormatter func(*model.FeedData)
 
-func NewRSSFeishu(config config.AgentConfig, dateFormatter ...DataFormatter) Agent {
+func init() {
+	Register(constants.ChannelTypeFeishu, func(cfg config.ChannelConfig, client *http.Client) (Agent, error) {
+		return NewRSSFeishu(cfg.AgentConfig, client), nil
+	})
+	RegisterFormatter(string(constants.AgentBestBlogs), BestBlogsFormatter)
+}
+
+func NewRSSFeishu(config config.AgentConfig, client *http.Client, dateFormatter ...DataFormatter) Agent {
 	feishu := &rssFeishu{
+		client:     client,
 		webhookURL: config.WebhookURL,
+		secret:     config.Secret,
 		length:     config.Length,
+		feishuLayouts: feishuLayouts{
+			card: config.Card,
+		},
+		itemTemplates: itemTemplates{
+			tmpl: config.Template.Compiled,
+		},
 	}
 	if len(dateFormatter) > 0 {
 		feishu.formatter = dateFormatter[0]
@@ -32,11 +53,25 @@ func (r *rssFeishu) Send(data model.FeedData) error {
 	if r.formatter != nil {
 		r.formatter(&data)
 	}
-	title, content, err := r.formatToMarkdown(data)
+	tmpl := r.template(data.Feed)
+	if r.useCard(data.Feed) {
+		cards, err := renderFeishuCards(data, r.length, r.layout(data.Feed), tmpl, feishuMaxBytes)
+		if err != nil {
+			return err
+		}
+		return sendFeishuCards(r.client, r.webhookURL, r.secret, cards)
+	}
+	var content [][]interface{}
+	var err error
+	if tmpl != nil {
+		content, err = templatePostContent(tmpl, data, r.length)
+	} else {
+		_, content, err = r.formatToMarkdown(data)
+	}
 	if err != nil {
 		return err
 	}
-	return SendToFeishu(r.webhookURL, title, content)
+	return sendFeishuPosts(r.client, r.webhookURL, r.secret, data.Title, content)
 }
 
 func (r *rssFeishu) SetFormatter(formatter DataFormatter) {
@@ -46,7 +81,7 @@ func (r *rssFeishu) SetFormatter(formatter DataFormatter) {
 func (r *rssFeishu) formatToMarkdown(data model.FeedData) (string, [][]interface{}, error) {
 	var content [][]interface{}
 	for i, item := range data.Items {
-		if i >= r.length {
+		if r.length > 0 && i >= r.length {
 			break
 		}
 		var row []interface{}
@@ -0,0 +1,221 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/render"
+)
+
+const (
+	slackMaxBlocks      = 50   // 单条消息的 block 数量上限
+	slackMaxHeaderText  = 150  // header 文本长度上限
+	slackMaxSectionText = 3000 // section 文本长度上限
+)
+
+// SlackBlock Slack Block Kit 中的一个 block
+type SlackBlock struct {
+	Type     string       `json:"type"`
+	Text     *SlackText   `json:"text,omitempty"`
+	Elements []*SlackText `json:"elements,omitempty"`
+}
+
+// SlackText Slack Block Kit 中的文本对象
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SlackMessage Slack incoming webhook 的消息结构
+type SlackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+type slack struct {
+	client     *http.Client
+	webhookURL string
+	length     int
+	formatter  DataFormatter
+	itemTemplates
+}
+
+func init() {
+	Register(constants.ChannelTypeSlack, func(cfg config.ChannelConfig, client *http.Client) (Agent, error) {
+		return NewSlack(cfg.AgentConfig, client), nil
+	})
+}
+
+// NewSlack 创建 Slack incoming webhook 代理
+func NewSlack(config config.AgentConfig, client *http.Client) Agent {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &slack{
+		client:     client,
+		webhookURL: config.WebhookURL,
+		length:     config.Length,
+		itemTemplates: itemTemplates{
+			tmpl: config.Template.Compiled,
+		},
+	}
+}
+
+func (s *slack) Send(data model.FeedData) error {
+	if s.formatter != nil {
+		s.formatter(&data)
+	}
+	messages, err := s.formatToBlocks(data)
+	if err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if err := s.post(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *slack) SetFormatter(formatter DataFormatter) {
+	s.formatter = formatter
+}
+
+// formatToBlocks 将数据渲染为 Block Kit 消息，超过 block 上限时拆分为多条
+func (s *slack) formatToBlocks(data model.FeedData) ([]SlackMessage, error) {
+	items, err := s.itemBlocks(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	var messages []SlackMessage
+	current := SlackMessage{Text: data.Title}
+	for _, blocks := range items {
+		if len(current.Blocks) == 0 {
+			current.Blocks = append(current.Blocks, SlackBlock{Type: "header"})
+		}
+		if len(current.Blocks)+len(blocks) > slackMaxBlocks {
+			messages = append(messages, current)
+			current = SlackMessage{Text: data.Title, Blocks: []SlackBlock{{Type: "header"}}}
+		}
+		current.Blocks = append(current.Blocks, blocks...)
+	}
+	messages = append(messages, current)
+
+	// 拆分为多条时在标题后标注序号
+	for i := range messages {
+		title := data.Title
+		if len(messages) > 1 {
+			title = fmt.Sprintf("%s (%d/%d)", title, i+1, len(messages))
+		}
+		messages[i].Blocks[0].Text = &SlackText{Type: "plain_text", Text: truncateRunes(title, slackMaxHeaderText)}
+	}
+	return messages, nil
+}
+
+// itemBlocks 将前 length 个条目渲染为 block，配置了模板时使用模板渲染
+func (s *slack) itemBlocks(data model.FeedData) ([][]SlackBlock, error) {
+	var items [][]SlackBlock
+	if tmpl := s.template(data.Feed); tmpl != nil {
+		texts, err := renderItems(tmpl, data, s.length)
+		if err != nil {
+			return nil, err
+		}
+		for _, text := range texts {
+			items = append(items, []SlackBlock{
+				{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: truncateRunes(slackMarkdown(text), slackMaxSectionText)}},
+				{Type: "divider"},
+			})
+		}
+		return items, nil
+	}
+	for i, item := range data.Items {
+		if s.length > 0 && i >= s.length {
+			break
+		}
+		text := "*" + slackLink(item.Title, item.Link) + "*"
+		summary := item.Summary
+		if summary == "" {
+			summary = item.Description
+		}
+		if summary != "" {
+			text += "\n" + slackEscape(summary)
+		}
+		context := "发布时间：" + item.Published.Format(time.DateTime)
+		if item.Author != "" {
+			context = slackEscape(item.Author) + " · " + context
+		}
+		items = append(items, []SlackBlock{
+			{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: truncateRunes(text, slackMaxSectionText)}},
+			{Type: "context", Elements: []*SlackText{{Type: "mrkdwn", Text: context}}},
+			{Type: "divider"},
+		})
+	}
+	return items, nil
+}
+
+// slackLink 生成 mrkdwn 链接，没有链接时只显示文本
+func slackLink(text, link string) string {
+	if link == "" {
+		return slackEscape(text)
+	}
+	return fmt.Sprintf("<%s|%s>", link, slackEscape(text))
+}
+
+func (s *slack) post(msg SlackMessage) error {
+	jsonValue, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.webhookURL, "application/json", bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return fmt.Errorf("failed to send message to Slack: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("slack API error: %s", string(body))
+	}
+	log.Info("Message sent to Slack successfully. Title:%s", msg.Text)
+	return nil
+}
+
+// slackEscape 转义 Slack mrkdwn 中的控制字符
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackMarkdown 将模板渲染的 markdown 转换为 mrkdwn：链接转换为 <地址|文本>，**加粗** 转换为 *加粗*
+func slackMarkdown(text string) string {
+	var b strings.Builder
+	for _, seg := range render.Segments(text) {
+		if seg.URL != "" {
+			fmt.Fprintf(&b, "<%s|%s>", seg.URL, slackEscape(seg.Text))
+			continue
+		}
+		b.WriteString(strings.ReplaceAll(slackEscape(seg.Text), "**", "*"))
+	}
+	return b.String()
+}
+
+// truncateRunes 按字符截断文本，超出部分以省略号代替
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestSlack(t *testing.T) {
+	var messages []SlackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg SlackMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		messages = append(messages, msg)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	data := model.FeedData{Title: "Best Blogs"}
+	for i := 0; i < 20; i++ {
+		data.Items = append(data.Items, model.FeedItem{
+			Title:     fmt.Sprintf("item <%d>", i),
+			Link:      fmt.Sprintf("https://example.com/%d", i),
+			Summary:   "summary",
+			Published: time.Now(),
+		})
+	}
+	ag := NewSlack(config.AgentConfig{WebhookURL: srv.URL, Length: 18}, srv.Client())
+	if err := ag.Send(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// 18 条，每条 3 个 block，加上 header 超过 50 个 block，需要拆分为两条
+	if len(messages) != 2 {
+		t.Fatalf("期望拆分为 2 条消息，实际 %d", len(messages))
+	}
+	sections := 0
+	for _, msg := range messages {
+		if len(msg.Blocks) > slackMaxBlocks || msg.Blocks[0].Type != "header" {
+			t.Fatalf("消息结构不符合预期: %+v", msg.Blocks[0])
+		}
+		for _, b := range msg.Blocks {
+			if b.Type == "section" {
+				sections++
+			}
+		}
+	}
+	if sections != 18 {
+		t.Fatalf("期望 18 条内容，实际 %d", sections)
+	}
+	if got := messages[0].Blocks[0].Text.Text; got != "Best Blogs (1/2)" {
+		t.Fatalf("标题不符合预期: %s", got)
+	}
+	if got := messages[0].Blocks[1].Text.Text; got != "*<https://example.com/0|item &lt;0&gt;>*\nsummary" {
+		t.Fatalf("条目内容不符合预期: %s", got)
+	}
+}
+
+func TestSlackItemWithoutLink(t *testing.T) {
+	s := NewSlack(config.AgentConfig{Length: 6}, nil).(*slack)
+	items, err := s.itemBlocks(model.FeedData{Items: []model.FeedItem{{Title: "no link"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := items[0][0].Text.Text; got != "*no link*" {
+		t.Fatalf("没有链接的条目不符合预期: %s", got)
+	}
+}
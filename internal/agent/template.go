@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/render"
+)
+
+// itemTemplates 渠道的条目消息模板，源可以覆盖渠道的模板
+type itemTemplates struct {
+	tmpl  *render.Template
+	feeds map[string]*render.Template
+}
+
+// SetTemplate 设置指定源的条目模板
+func (t *itemTemplates) SetTemplate(feed string, tmpl *render.Template) {
+	if t.feeds == nil {
+		t.feeds = make(map[string]*render.Template)
+	}
+	t.feeds[feed] = tmpl
+}
+
+// template 返回源使用的模板，为 nil 时使用渠道的默认布局
+func (t *itemTemplates) template(feed string) *render.Template {
+	if tmpl, ok := t.feeds[feed]; ok && tmpl != nil {
+		return tmpl
+	}
+	return t.tmpl
+}
+
+// renderItems 用模板渲染前 length 个条目
+func renderItems(tmpl *render.Template, data model.FeedData, length int) ([]string, error) {
+	var texts []string
+	for i, item := range data.Items {
+		if length > 0 && i >= length {
+			break
+		}
+		text, err := tmpl.Execute(data, i, item)
+		if err != nil {
+			return nil, err
+		}
+		texts = append(texts, text)
+	}
+	return texts, nil
+}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/render"
+)
+
+func TestItemTemplates(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		unescape := strings.NewReplacer(`\u003c`, "<", `\u003e`, ">", `\u0026`, "&")
+		bodies = append(bodies, unescape.Replace(string(body)))
+		w.Write([]byte(`{"code":0,"errcode":0}`))
+	}))
+	defer srv.Close()
+
+	channelTmpl, err := render.Parse("channel", "**[{{.Title}}]({{.Link}})**")
+	if err != nil {
+		t.Fatal(err)
+	}
+	feedTmpl, err := render.Parse("feed", "{{.Index}}: {{.Title}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.AgentConfig{WebhookURL: srv.URL, Length: 5, Template: config.TemplateConfig{Compiled: channelTmpl}}
+	data := model.FeedData{
+		Feed:  "blogs",
+		Title: "Blogs",
+		Items: []model.FeedItem{{Title: "a<b", Link: "https://example.com/a"}},
+	}
+
+	tests := []struct {
+		name  string
+		agent Agent
+		want  string
+	}{
+		{"feishu", NewRSSFeishu(cfg, srv.Client()), `{"tag":"a","text":"a<b","href":"https://example.com/a"}`},
+		{"slack", NewSlack(cfg, srv.Client()), `*<https://example.com/a|a&lt;b>*`},
+		{"dingtalk", NewDingTalk(cfg, srv.Client()), `**[a<b](https://example.com/a)**`},
+		{"wecom", NewWeCom(cfg, srv.Client()), `**[a<b](https://example.com/a)**`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = nil
+			if err := tt.agent.Send(data); err != nil {
+				t.Fatal(err)
+			}
+			if len(bodies) != 1 || !strings.Contains(bodies[0], tt.want) {
+				t.Fatalf("期望包含 %s，实际 %v", tt.want, bodies)
+			}
+
+			// 源的模板覆盖渠道的模板
+			tt.agent.(TemplateSetter).SetTemplate("blogs", feedTmpl)
+			bodies = nil
+			if err := tt.agent.Send(data); err != nil {
+				t.Fatal(err)
+			}
+			if len(bodies) != 1 || !strings.Contains(bodies[0], "1: a") {
+				t.Fatalf("源模板未生效: %v", bodies)
+			}
+		})
+	}
+}
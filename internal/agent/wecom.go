@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+const (
+	weComMaxBytes   = 4096 // markdown 内容长度上限
+	weComRateLimit  = 20   // 每个机器人每分钟最多发送 20 条
+	weComRateWindow = time.Minute
+)
+
+// WeComMessage 企业微信群机器人 markdown 消息
+type WeComMessage struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Content string `json:"content"`
+	} `json:"markdown"`
+}
+
+type weCom struct {
+	client     *http.Client
+	webhookURL string
+	length     int
+	formatter  DataFormatter
+	limiter    *rateLimiter
+	itemTemplates
+}
+
+func init() {
+	Register(constants.ChannelTypeWeCom, func(cfg config.ChannelConfig, client *http.Client) (Agent, error) {
+		return NewWeCom(cfg.AgentConfig, client), nil
+	})
+}
+
+// NewWeCom 创建企业微信群机器人代理
+func NewWeCom(config config.AgentConfig, client *http.Client) Agent {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &weCom{
+		client:     client,
+		webhookURL: config.WebhookURL,
+		length:     config.Length,
+		itemTemplates: itemTemplates{
+			tmpl: config.Template.Compiled,
+		},
+		limiter: newRateLimiter(weComRateLimit, weComRateWindow),
+	}
+}
+
+func (w *weCom) Send(data model.FeedData) error {
+	if w.formatter != nil {
+		w.formatter(&data)
+	}
+	items, err := markdownItems(data, w.length, w.template(data.Feed))
+	if err != nil {
+		return err
+	}
+	for _, content := range splitMarkdownItems(data.Title, items, weComMaxBytes) {
+		var msg WeComMessage
+		msg.MsgType = "markdown"
+		msg.Markdown.Content = content
+		if err := w.post(data.Title, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *weCom) SetFormatter(formatter DataFormatter) {
+	w.formatter = formatter
+}
+
+func (w *weCom) post(title string, msg WeComMessage) error {
+	jsonValue, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	w.limiter.Wait()
+	resp, err := w.client.Post(w.webhookURL, "application/json", bytes.NewBuffer(jsonValue))
+	if err != nil {
+		return fmt.Errorf("failed to send message to WeCom: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wecom API error: %s", string(body))
+	}
+	var result robotResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("wecom API error: %s", string(body))
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("wecom API error: %d %s", result.ErrCode, result.ErrMsg)
+	}
+	log.Info("Message sent to WeCom successfully. Title:%s", title)
+	return nil
+}
@@ -1,22 +1,39 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
 	"github.com/spf13/viper"
 	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/render"
+	"github.com/weirwei/rss-agent/internal/urltmpl"
 )
 
 // FeedConfig 表示一个源的配置
 type FeedConfig struct {
 	URL      string
-	Dynamic  bool
-	Template string
-	Format   string
+	Dynamic  *urltmpl.Template // 动态地址，配置后忽略 URL，按偏移依次尝试生成的地址
+	Interval time.Duration     // 抓取间隔，为 0 时使用全局间隔
+	Cron     string            // cron 表达式，优先于 Interval
+	Jitter   time.Duration     // 随机抖动上限
+	Sanitize SanitizeConfig    // 抓取后的内容清洗
+	Filter   FilterConfig      // 发送前的条目过滤规则
 }
 
 type Config struct {
 	App       AppConfig                           `mapstructure:"app"`
-	Feishu    map[constants.AgentType]AgentConfig `mapstructure:"feishu"`
+	Feishu    map[constants.AgentType]AgentConfig `mapstructure:"feishu"`   // 旧版配置，加载时转换为同名渠道
+	DingTalk  map[string]AgentConfig              `mapstructure:"dingtalk"` // 加载时转换为同名渠道
+	WeCom     map[string]AgentConfig              `mapstructure:"wecom"`    // 加载时转换为同名渠道
+	Channels  []ChannelConfig                     `mapstructure:"channels"`
 	Fetcher   FetcherConfig                       `mapstructure:"fetcher"`
+	Store     StoreConfig                         `mapstructure:"store"`
+	HTTP      HTTPConfig                          `mapstructure:"http"`
+	Outbox    OutboxConfig                        `mapstructure:"outbox"`
+	Routing   RoutingConfig                       `mapstructure:"routing"`
 	OutputDir string                              `mapstructure:"output_dir"`
 }
 
@@ -24,28 +41,228 @@ type AppConfig struct {
 	Name string `mapstructure:"name"`
 }
 
+// StoreConfig 状态存储配置
+type StoreConfig struct {
+	Type string `mapstructure:"type"` // json 或 bolt，默认 json
+	Path string `mapstructure:"path"` // json 为目录，bolt 为数据库文件
+}
+
+// OutboxConfig 发件箱重试配置
+type OutboxConfig struct {
+	Interval    int `mapstructure:"interval"`     // 检查待重试消息的间隔，单位秒，默认 30
+	MaxAttempts int `mapstructure:"max_attempts"` // 最大尝试次数，超过后移入死信队列，默认 5
+	BackoffBase int `mapstructure:"backoff_base"` // 首次重试等待时间，单位秒，默认 60
+	BackoffMax  int `mapstructure:"backoff_max"`  // 重试等待时间上限，单位秒，默认 3600
+}
+
+// HTTPConfig 所有抓取器和代理共享的 HTTP 客户端配置
+type HTTPConfig struct {
+	Timeout     int                   `mapstructure:"timeout"`       // 请求超时，单位秒，默认 30
+	Proxy       string                `mapstructure:"proxy"`         // 代理地址，为空时使用环境变量
+	UserAgent   string                `mapstructure:"user_agent"`    // 自定义 User-Agent
+	MaxBodySize int64                 `mapstructure:"max_body_size"` // 响应体大小上限，单位字节，默认 10MB
+	TLS         TLSConfig             `mapstructure:"tls"`
+	Hosts       map[string]HostConfig `mapstructure:"hosts"` // 按域名附加的请求头和 Cookie
+}
+
+type TLSConfig struct {
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+	CAFile             string `mapstructure:"ca_file"`
+}
+
+type HostConfig struct {
+	Headers map[string]string `mapstructure:"headers"`
+	Cookies []CookieConfig    `mapstructure:"cookies"`
+}
+
+type CookieConfig struct {
+	Name  string `mapstructure:"name"`
+	Value string `mapstructure:"value"`
+}
+
 type AgentConfig struct {
-	WebhookURL string `mapstructure:"webhook_url"`
-	Cron       string `mapstructure:"cron"`
-	Length     int    `mapstructure:"length"`
+	WebhookURL string         `mapstructure:"webhook_url"`
+	Cron       string         `mapstructure:"cron"`
+	Length     int            `mapstructure:"length"`     // 每条消息最多发送的条目数，0 表示不限制
+	Secret     string         `mapstructure:"secret"`     // 加签密钥
+	Keyword    string         `mapstructure:"keyword"`    // 钉钉自定义关键词，消息中必须包含
+	Card       CardConfig     `mapstructure:"card"`       // 飞书消息卡片布局，源未单独配置时使用
+	Template   TemplateConfig `mapstructure:"template"`   // 条目消息模板，源未单独配置时使用
+	Digest     DigestConfig   `mapstructure:"digest"`     // 汇总发送，开启后按 Cron 发送上次汇总以来的新增条目
+	NoUpdates  string         `mapstructure:"no_updates"` // 按 Cron 发送时没有未发送过的条目：skip 跳过（默认），notify 发送“暂无更新”提示
+}
+
+// DigestConfig 汇总发送：源的新增条目先进入渠道的汇总队列，按渠道的 cron 按源分组发送
+type DigestConfig struct {
+	Enabled  bool `mapstructure:"enabled"`
+	MaxItems int  `mapstructure:"max_items"` // 每个源最多显示的条目数，默认为渠道的 length，超出的条目同样标记为已发送
+}
+
+// TemplateConfig 条目消息模板，Text 为内联的 text/template 模板，File 为模板文件路径，
+// 都为空时使用渠道的默认布局。加载配置时解析到 Compiled
+type TemplateConfig struct {
+	Text     string           `mapstructure:"text"`
+	File     string           `mapstructure:"file"`
+	Compiled *render.Template `mapstructure:"-"`
+}
+
+// CardConfig 飞书消息卡片布局
+type CardConfig struct {
+	Format       string `mapstructure:"format"`         // 消息格式：card 为交互式卡片，post 为富文本，默认 post
+	Template     string `mapstructure:"template"`       // 卡片标题栏颜色，如 blue、green、orange，默认 blue
+	HideSummary  bool   `mapstructure:"hide_summary"`   // 不显示摘要
+	HideNote     bool   `mapstructure:"hide_note"`      // 不显示作者和发布时间
+	HideImage    bool   `mapstructure:"hide_image"`     // 不显示条目图片
+	HideButtons  bool   `mapstructure:"hide_buttons"`   // 不显示按钮
+	ReadLaterURL string `mapstructure:"read_later_url"` // “稍后阅读”按钮的地址，{{url}} 替换为条目链接，为空时不显示该按钮
+}
+
+// ChannelConfig 命名的发送渠道，Type 对应 agent 包中注册的渠道类型
+type ChannelConfig struct {
+	Name        string `mapstructure:"name"`
+	Type        string `mapstructure:"type"`
+	AgentConfig `mapstructure:",squash"`
+	Options     map[string]interface{} `mapstructure:",remain"` // 渠道类型特有的配置
 }
 
 type FetcherConfig struct {
 	Interval    int               `mapstructure:"interval"`
+	Workers     int               `mapstructure:"workers"`  // 并发抓取数，默认 4
+	Timeout     int               `mapstructure:"timeout"`  // 单个源的抓取时限，单位秒，默认 60
+	Sanitize    SanitizeConfig    `mapstructure:"sanitize"` // 所有源默认的内容清洗配置
+	Health      HealthConfig      `mapstructure:"health"`
 	ProductHunt ProductHuntConfig `mapstructure:"product_hunt"`
 	RSS         []RSSConfig       `mapstructure:"rss"`
+	// Subscriptions 订阅文件路径，文件中的 rss 列表追加到 RSS 之后，由 import-opml 命令生成
+	Subscriptions string `mapstructure:"subscriptions"`
+}
+
+// SanitizeConfig 将条目的摘要和描述从 HTML 转换为可读文本
+type SanitizeConfig struct {
+	Format    string `mapstructure:"format"`     // text 为纯文本，markdown 为轻量 markdown，为空时不清洗
+	MaxLength int    `mapstructure:"max_length"` // 摘要和描述的最大字符数，按词截断，0 表示不限制
+}
+
+// HealthConfig 抓取失败的退避、暂停和告警配置
+type HealthConfig struct {
+	BackoffBase int    `mapstructure:"backoff_base"` // 首次失败后的等待时间，单位分钟，默认 1
+	BackoffMax  int    `mapstructure:"backoff_max"`  // 等待时间上限，单位分钟，默认 360
+	AlertAfter  int    `mapstructure:"alert_after"`  // 连续失败多少次后告警，默认 3
+	PauseAfter  int    `mapstructure:"pause_after"`  // 连续失败多少次后暂停，默认 10
+	Alert       string `mapstructure:"alert"`        // 发送告警的渠道名称，为空时不告警
 }
 
 type ProductHuntConfig struct {
-	Enabled bool `mapstructure:"enabled"`
-	Length  int  `mapstructure:"length"`
+	Enabled  bool          `mapstructure:"enabled"`
+	Length   int           `mapstructure:"length"`
+	Channels []string      `mapstructure:"channels"`
+	Dynamic  DynamicConfig `mapstructure:"dynamic"` // 页面的动态地址，未配置时依次尝试 decohack 今天和昨天的日报
+	Scrape   ScrapeConfig  `mapstructure:"scrape"`  // 页面的抓取规则，未配置时使用 decohack 日报的默认规则
+}
+
+// DynamicConfig 动态地址：Template 为 text/template 模板，抓取时按 Offsets 中的每个日期偏移（天）
+// 依次生成地址，直到抓取成功。可用的变量和函数见 urltmpl 包
+type DynamicConfig struct {
+	Template string            `mapstructure:"template"`
+	Format   string            `mapstructure:"format"`   // {{date}} 的日期格式，默认 2006-01-02
+	Timezone string            `mapstructure:"timezone"` // 计算日期使用的时区，默认本地时区
+	Offsets  []int             `mapstructure:"offsets"`  // 依次尝试的日期偏移，默认 [0]
+	Compiled *urltmpl.Template `mapstructure:"-"`
+}
+
+// ScrapeConfig 按 CSS 选择器从 HTML 页面抓取条目。Item 选中每个条目的起始元素，
+// 条目的范围为该元素及其后直到下一个条目的兄弟元素，因此既适用于每个条目有外层容器的页面，
+// 也适用于条目由标题和若干段落平铺组成的页面。字段选择器在条目范围内查找
+type ScrapeConfig struct {
+	Title       string       `mapstructure:"title"`       // 源标题，为空时使用页面的 <title>
+	Description string       `mapstructure:"description"` // 源描述
+	Item        string       `mapstructure:"item"`
+	Fields      ScrapeFields `mapstructure:"fields"`
+	DateFormat  string       `mapstructure:"date_format"` // 日期字段的 Go 时间格式，默认 RFC3339
+	Timezone    string       `mapstructure:"timezone"`    // 日期字段的时区，默认本地时区
+}
+
+// ScrapeFields 条目各字段的选择器
+type ScrapeFields struct {
+	Title       FieldSelector `mapstructure:"title"`
+	Link        FieldSelector `mapstructure:"link"`
+	Summary     FieldSelector `mapstructure:"summary"`
+	Description FieldSelector `mapstructure:"description"`
+	Image       FieldSelector `mapstructure:"image"`
+	Date        FieldSelector `mapstructure:"date"`
+	Author      FieldSelector `mapstructure:"author"`
+}
+
+// FieldSelector 字段选择器：Selector 为空时使用条目的起始元素；Attr 为空时取文本，为 html 时取内部 HTML，
+// 否则取该属性；配置了 Pattern 时取正则的第一个分组（没有分组时取整个匹配）。
+// 选中多个元素时使用第一个取到非空值的元素
+type FieldSelector struct {
+	Selector string `mapstructure:"selector"`
+	Attr     string `mapstructure:"attr"`
+	Pattern  string `mapstructure:"pattern"`
 }
 
 type RSSConfig struct {
-	Name    constants.AgentName `mapstructure:"name"`
-	URL     string              `mapstructure:"url"`
-	Send    bool                `mapstructure:"send"`
-	Enabled bool                `mapstructure:"enabled"`
+	Name     constants.AgentName `mapstructure:"name"`
+	URL      string              `mapstructure:"url"`
+	Send     bool                `mapstructure:"send"`
+	Enabled  bool                `mapstructure:"enabled"`
+	Interval int                 `mapstructure:"interval"` // 抓取间隔，单位分钟，为 0 时使用 fetcher.interval
+	Cron     string              `mapstructure:"cron"`     // cron 表达式，优先于 interval
+	Jitter   int                 `mapstructure:"jitter"`   // 随机抖动上限，单位秒
+	// Channels 发送渠道名称，配置了 cron 的渠道按计划发送最新快照，其余渠道立即发送增量
+	Channels  []string `mapstructure:"channels"`
+	Formatter string   `mapstructure:"formatter"` // 发送前的数据格式化器，如 best-blogs
+	// Card 该源在飞书渠道中的卡片布局，覆盖渠道的 card 配置
+	Card *CardConfig `mapstructure:"card"`
+	// Sanitize 该源的内容清洗配置，覆盖 fetcher.sanitize
+	Sanitize *SanitizeConfig `mapstructure:"sanitize"`
+	// Template 该源的条目消息模板，覆盖渠道的 template 配置
+	Template *TemplateConfig `mapstructure:"template"`
+	// Filter 发送前对新增条目的过滤规则
+	Filter FilterConfig `mapstructure:"filter"`
+	// Scrape 配置后按选择器抓取 HTML 页面，而不是解析 RSS/Atom/JSON Feed
+	Scrape *ScrapeConfig `mapstructure:"scrape"`
+	// Dynamic 配置后按模板生成抓取地址，忽略 url
+	Dynamic *DynamicConfig `mapstructure:"dynamic"`
+	// Title 显示名称，导入 OPML 时来自订阅的标题，导出时为空则使用 name
+	Title string `mapstructure:"title"`
+	// Tags 分组标签，导入 OPML 时来自订阅所在的分类，导出时按第一个标签分组
+	Tags []string `mapstructure:"tags"`
+}
+
+// RoutingConfig 按内容将条目路由到渠道。条目会发送到所有匹配规则的渠道，
+// 未匹配任何规则时发送到 Default，Default 为空时发送到源自己的 channels
+type RoutingConfig struct {
+	Rules   []RouteConfig `mapstructure:"rules"`
+	Default []string      `mapstructure:"default"`
+}
+
+// RouteConfig 一条路由规则，匹配条件与过滤规则相同
+type RouteConfig struct {
+	Channels   []string `mapstructure:"channels"` // 匹配的条目发送到的渠道
+	Feeds      []string `mapstructure:"feeds"`    // 生效的源，为空时对所有源生效
+	FilterRule `mapstructure:",squash"`
+}
+
+// FilterConfig 条目过滤规则：配置了 include 时只保留匹配任一 include 规则的条目，
+// 再丢弃匹配任一 exclude 规则的条目
+type FilterConfig struct {
+	Include []FilterRule `mapstructure:"include"`
+	Exclude []FilterRule `mapstructure:"exclude"`
+}
+
+// FilterRule 一条过滤规则，由多个条件组成，Mode 为 and 时所有条件都满足才匹配，为 or 时任一条件满足即匹配
+type FilterRule struct {
+	Name      string   `mapstructure:"name"`       // 规则名称，用于日志，默认为 include-1、exclude-1 等
+	Mode      string   `mapstructure:"mode"`       // and 或 or，默认 or
+	Fields    []string `mapstructure:"fields"`     // 关键词和正则匹配的字段：title、summary、description、author、link，默认 title 和 summary
+	Keywords  []string `mapstructure:"keywords"`   // 关键词，不区分大小写，每个关键词是一个条件
+	Regex     string   `mapstructure:"regex"`      // 正则表达式，在任一字段中匹配即满足
+	Authors   []string `mapstructure:"authors"`    // 作者，不区分大小写，等于其中任一个即满足
+	Domains   []string `mapstructure:"domains"`    // 链接域名，等于其中任一个或是其子域名即满足
+	OlderThan int      `mapstructure:"older_than"` // 发布时间早于多少小时前即满足
+	NewerThan int      `mapstructure:"newer_than"` // 发布时间晚于多少小时前即满足
 }
 
 func Load() (*Config, error) {
@@ -62,5 +279,432 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	if err := config.loadSubscriptions(); err != nil {
+		return nil, err
+	}
+	config.normalizeChannels()
+	if err := config.validateFeedNames(); err != nil {
+		return nil, err
+	}
+	if err := config.validateCards(); err != nil {
+		return nil, err
+	}
+	if err := config.validateSanitize(); err != nil {
+		return nil, err
+	}
+	if err := config.compileTemplates(); err != nil {
+		return nil, err
+	}
+	if err := config.validateFilters(); err != nil {
+		return nil, err
+	}
+	if err := config.validateRouting(); err != nil {
+		return nil, err
+	}
+	if err := config.validateDigests(); err != nil {
+		return nil, err
+	}
+	if err := config.validateNoUpdates(); err != nil {
+		return nil, err
+	}
+	config.Fetcher.ProductHunt.setDefaults()
+	if err := config.validateScrapes(); err != nil {
+		return nil, err
+	}
+	if err := config.compileDynamics(); err != nil {
+		return nil, err
+	}
+
+	if config.Store.Type == "" {
+		config.Store.Type = "json"
+	}
+	if config.Store.Path == "" && config.Store.Type == "json" {
+		config.Store.Path = config.OutputDir
+		if config.Store.Path == "" {
+			config.Store.Path = "rss_output"
+		}
+	}
+
 	return &config, nil
 }
+
+// loadSubscriptions 读取订阅文件，文件不存在时忽略
+func (c *Config) loadSubscriptions() error {
+	if c.Fetcher.Subscriptions == "" {
+		return nil
+	}
+	if _, err := os.Stat(c.Fetcher.Subscriptions); os.IsNotExist(err) {
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigFile(c.Fetcher.Subscriptions)
+	if err := v.ReadInConfig(); err != nil {
+		return fmt.Errorf("读取订阅文件失败 %s: %v", c.Fetcher.Subscriptions, err)
+	}
+	var feeds []RSSConfig
+	if err := v.UnmarshalKey("rss", &feeds); err != nil {
+		return fmt.Errorf("解析订阅文件失败 %s: %v", c.Fetcher.Subscriptions, err)
+	}
+	c.Fetcher.RSS = append(c.Fetcher.RSS, feeds...)
+	return nil
+}
+
+// reservedFeedNames JSON 存储的状态文件与源快照 <name>.json 位于同一目录，源不能使用这些名称
+var reservedFeedNames = map[string]bool{
+	"validators":   true,
+	"health":       true,
+	"outbox":       true,
+	"dead_letters": true,
+	"digest":       true,
+}
+
+// IsReservedFeedName 源名称是否与存储的状态文件冲突
+func IsReservedFeedName(name string) bool {
+	return reservedFeedNames[name]
+}
+
+// validateFeedNames 检查源名称不为空、不重复且不与存储的状态文件冲突
+func (c *Config) validateFeedNames() error {
+	names := make(map[constants.AgentName]bool)
+	for _, rss := range c.Fetcher.RSS {
+		if rss.Name == "" {
+			return fmt.Errorf("源没有配置 name: %s", rss.URL)
+		}
+		if IsReservedFeedName(string(rss.Name)) {
+			return fmt.Errorf("源名称 %s 为保留名称，请更换", rss.Name)
+		}
+		if names[rss.Name] {
+			return fmt.Errorf("源名称重复: %s", rss.Name)
+		}
+		names[rss.Name] = true
+	}
+	return nil
+}
+
+// validateCards 检查渠道和源的飞书消息格式
+func (c *Config) validateCards() error {
+	check := func(name string, card CardConfig) error {
+		switch card.Format {
+		case "", constants.FeishuFormatPost, constants.FeishuFormatCard:
+			return nil
+		}
+		return fmt.Errorf("未知的飞书消息格式 %s: %s", name, card.Format)
+	}
+	for _, ch := range c.Channels {
+		if err := check(ch.Name, ch.Card); err != nil {
+			return err
+		}
+	}
+	for _, rss := range c.Fetcher.RSS {
+		if rss.Card != nil {
+			if err := check(string(rss.Name), *rss.Card); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateSanitize 检查内容清洗格式
+func (c *Config) validateSanitize() error {
+	check := func(name string, sanitize SanitizeConfig) error {
+		switch sanitize.Format {
+		case "", constants.SanitizeText, constants.SanitizeMarkdown:
+			return nil
+		}
+		return fmt.Errorf("未知的内容清洗格式 %s: %s", name, sanitize.Format)
+	}
+	if err := check("fetcher", c.Fetcher.Sanitize); err != nil {
+		return err
+	}
+	for _, rss := range c.Fetcher.RSS {
+		if rss.Sanitize != nil {
+			if err := check(string(rss.Name), *rss.Sanitize); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compileTemplates 读取并解析渠道和源的消息模板
+func (c *Config) compileTemplates() error {
+	for i := range c.Channels {
+		if err := c.Channels[i].Template.compile(c.Channels[i].Name); err != nil {
+			return err
+		}
+	}
+	for _, rss := range c.Fetcher.RSS {
+		if rss.Template != nil {
+			if err := rss.Template.compile(string(rss.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (t *TemplateConfig) compile(name string) error {
+	if t.Text != "" && t.File != "" {
+		return fmt.Errorf("模板 %s 不能同时配置 text 和 file", name)
+	}
+	if t.File != "" {
+		text, err := os.ReadFile(t.File)
+		if err != nil {
+			return fmt.Errorf("读取模板 %s 失败: %v", name, err)
+		}
+		t.Text = string(text)
+	}
+	if t.Text == "" {
+		return nil
+	}
+	compiled, err := render.Parse(name, t.Text)
+	if err != nil {
+		return fmt.Errorf("解析模板 %s 失败: %v", name, err)
+	}
+	t.Compiled = compiled
+	return nil
+}
+
+// validateFilters 检查过滤规则的组合方式、字段和正则表达式
+func (c *Config) validateFilters() error {
+	for _, rss := range c.Fetcher.RSS {
+		for _, rule := range append(append([]FilterRule{}, rss.Filter.Include...), rss.Filter.Exclude...) {
+			if err := rule.validate(); err != nil {
+				return fmt.Errorf("源 %s 的过滤规则 %s 无效: %v", rss.Name, rule.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r FilterRule) validate() error {
+	switch r.Mode {
+	case "", constants.FilterModeAnd, constants.FilterModeOr:
+	default:
+		return fmt.Errorf("未知的组合方式: %s", r.Mode)
+	}
+	for _, field := range r.Fields {
+		switch field {
+		case constants.FilterFieldTitle, constants.FilterFieldSummary, constants.FilterFieldDescription,
+			constants.FilterFieldAuthor, constants.FilterFieldLink, constants.FilterFieldCategory:
+		default:
+			return fmt.Errorf("未知的字段: %s", field)
+		}
+	}
+	if r.Regex != "" {
+		if _, err := regexp.Compile(r.Regex); err != nil {
+			return err
+		}
+	}
+	if len(r.Keywords) == 0 && r.Regex == "" && len(r.Authors) == 0 && len(r.Domains) == 0 && r.OlderThan <= 0 && r.NewerThan <= 0 {
+		return fmt.Errorf("规则没有任何条件")
+	}
+	return nil
+}
+
+// validateRouting 检查路由规则的条件和渠道
+func (c *Config) validateRouting() error {
+	for i, route := range c.Routing.Rules {
+		name := route.Name
+		if name == "" {
+			name = fmt.Sprintf("route-%d", i+1)
+		}
+		if err := route.validate(); err != nil {
+			return fmt.Errorf("路由规则 %s 无效: %v", name, err)
+		}
+		if len(route.Channels) == 0 {
+			return fmt.Errorf("路由规则 %s 没有配置渠道", name)
+		}
+		for _, channel := range route.Channels {
+			if _, ok := c.Channel(channel); !ok {
+				return fmt.Errorf("路由规则 %s 的渠道不存在: %s", name, channel)
+			}
+		}
+	}
+	for _, channel := range c.Routing.Default {
+		if _, ok := c.Channel(channel); !ok {
+			return fmt.Errorf("默认路由的渠道不存在: %s", channel)
+		}
+	}
+	return nil
+}
+
+// validateDigests 检查汇总发送的渠道都配置了 cron，并设置每个源的条目上限
+func (c *Config) validateDigests() error {
+	for i, ch := range c.Channels {
+		if !ch.Digest.Enabled {
+			continue
+		}
+		if ch.Cron == "" {
+			return fmt.Errorf("汇总发送的渠道没有配置 cron: %s", ch.Name)
+		}
+		if ch.Digest.MaxItems <= 0 {
+			c.Channels[i].Digest.MaxItems = ch.Length
+		}
+	}
+	return nil
+}
+
+// validateNoUpdates 检查定时发送没有新条目时的处理方式
+func (c *Config) validateNoUpdates() error {
+	for _, ch := range c.Channels {
+		switch ch.NoUpdates {
+		case "", constants.NoUpdatesSkip, constants.NoUpdatesNotify:
+		default:
+			return fmt.Errorf("未知的无更新处理方式 %s: %s", ch.Name, ch.NoUpdates)
+		}
+	}
+	return nil
+}
+
+// setDefaults ProductHunt 源未配置时使用 decohack 日报的地址和抓取规则
+func (p *ProductHuntConfig) setDefaults() {
+	if p.Dynamic.Template == "" {
+		p.Dynamic = DynamicConfig{
+			Template: "https://decohack.com/producthunt-daily-{{date}}/",
+			Timezone: "Asia/Shanghai",
+			Offsets:  []int{0, -1},
+		}
+	}
+	if p.Scrape.Item == "" {
+		p.Scrape = ScrapeConfig{
+			Title:       "ProductHunt Daily",
+			Description: "Daily ProductHunt Updates",
+			Item:        "h2:has(a)",
+			Fields: ScrapeFields{
+				Title:       FieldSelector{Selector: "a", Pattern: `^(?:\d+\.\s*)?(.+)$`},
+				Link:        FieldSelector{Selector: "a", Attr: "href"},
+				Summary:     FieldSelector{Selector: "p", Attr: "html", Pattern: `<strong>标语</strong>：([^<]+)`},
+				Description: FieldSelector{Selector: "p", Attr: "html", Pattern: `<strong>介绍</strong>：([^<]+)`},
+				Image:       FieldSelector{Selector: "img", Attr: "src"},
+			},
+		}
+	}
+}
+
+// validateScrapes 检查 HTML 抓取规则
+func (c *Config) validateScrapes() error {
+	check := func(name string, scrape ScrapeConfig) error {
+		if scrape.Item == "" {
+			return fmt.Errorf("源 %s 的抓取规则没有配置 item", name)
+		}
+		if scrape.Fields.Title.Selector == "" && scrape.Fields.Link.Selector == "" {
+			return fmt.Errorf("源 %s 的抓取规则没有配置 title 或 link", name)
+		}
+		for _, field := range []FieldSelector{scrape.Fields.Title, scrape.Fields.Link, scrape.Fields.Summary,
+			scrape.Fields.Description, scrape.Fields.Image, scrape.Fields.Date, scrape.Fields.Author} {
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				return fmt.Errorf("源 %s 的抓取规则正则表达式无效: %v", name, err)
+			}
+		}
+		if scrape.Timezone != "" {
+			if _, err := time.LoadLocation(scrape.Timezone); err != nil {
+				return fmt.Errorf("源 %s 的抓取规则时区无效: %v", name, err)
+			}
+		}
+		return nil
+	}
+	if c.Fetcher.ProductHunt.Enabled {
+		if err := check(string(constants.AgentPH), c.Fetcher.ProductHunt.Scrape); err != nil {
+			return err
+		}
+	}
+	for _, rss := range c.Fetcher.RSS {
+		if rss.Scrape != nil {
+			if err := check(string(rss.Name), *rss.Scrape); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compileDynamics 解析源的动态地址模板
+func (c *Config) compileDynamics() error {
+	if c.Fetcher.ProductHunt.Enabled {
+		if err := c.Fetcher.ProductHunt.Dynamic.compile(string(constants.AgentPH)); err != nil {
+			return err
+		}
+	}
+	for _, rss := range c.Fetcher.RSS {
+		if rss.Dynamic != nil {
+			if err := rss.Dynamic.compile(string(rss.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *DynamicConfig) compile(name string) error {
+	if d.Template == "" {
+		return fmt.Errorf("源 %s 的动态地址没有配置 template", name)
+	}
+	compiled, err := urltmpl.Parse(d.Template, d.Format, d.Timezone, d.Offsets)
+	if err != nil {
+		return fmt.Errorf("源 %s 的动态地址无效: %v", name, err)
+	}
+	d.Compiled = compiled
+	return nil
+}
+
+// Channel 按名称查找渠道
+func (c *Config) Channel(name string) (ChannelConfig, bool) {
+	for _, ch := range c.Channels {
+		if ch.Name == name {
+			return ch, true
+		}
+	}
+	return ChannelConfig{}, false
+}
+
+// normalizeChannels 兼容旧版配置：feishu、dingtalk、wecom 下的每个机器人转换为同名渠道，
+// 未声明渠道的源沿用原来的机器人
+func (c *Config) normalizeChannels() {
+	for key, agentConfig := range c.Feishu {
+		if _, ok := c.Channel(string(key)); ok {
+			continue
+		}
+		channelType := constants.ChannelTypeFeishu
+		if key == constants.AgentTypePH {
+			channelType = constants.ChannelTypeFeishuPH
+		}
+		c.Channels = append(c.Channels, ChannelConfig{
+			Name:        string(key),
+			Type:        channelType,
+			AgentConfig: agentConfig,
+		})
+	}
+	for _, robots := range []struct {
+		channelType string
+		configs     map[string]AgentConfig
+	}{
+		{constants.ChannelTypeDingTalk, c.DingTalk},
+		{constants.ChannelTypeWeCom, c.WeCom},
+	} {
+		for name, agentConfig := range robots.configs {
+			if _, ok := c.Channel(name); ok {
+				continue
+			}
+			c.Channels = append(c.Channels, ChannelConfig{
+				Name:        name,
+				Type:        robots.channelType,
+				AgentConfig: agentConfig,
+			})
+		}
+	}
+	if len(c.Fetcher.ProductHunt.Channels) == 0 {
+		if _, ok := c.Channel(string(constants.AgentTypePH)); ok {
+			c.Fetcher.ProductHunt.Channels = []string{string(constants.AgentTypePH)}
+		}
+	}
+	for i, rss := range c.Fetcher.RSS {
+		if rss.Send && len(rss.Channels) == 0 {
+			c.Fetcher.RSS[i].Channels = []string{string(constants.AgentTypeRSS)}
+		}
+		if rss.Formatter == "" && rss.Name == constants.AgentBestBlogs {
+			c.Fetcher.RSS[i].Formatter = string(constants.AgentBestBlogs)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+)
+
+func TestNormalizeChannels(t *testing.T) {
+	c := Config{
+		Feishu: map[constants.AgentType]AgentConfig{
+			constants.AgentTypePH:  {WebhookURL: "ph", Cron: "0 16 * * *"},
+			constants.AgentTypeRSS: {WebhookURL: "rss"},
+		},
+		Channels: []ChannelConfig{{Name: "ai", Type: "feishu"}},
+		Fetcher: FetcherConfig{
+			RSS: []RSSConfig{
+				{Name: constants.AgentBestBlogs, Send: true},
+				{Name: "other", Send: true, Channels: []string{"ai"}},
+			},
+		},
+	}
+	c.normalizeChannels()
+
+	if ph, ok := c.Channel(string(constants.AgentTypePH)); !ok || ph.Type != constants.ChannelTypeFeishuPH || ph.Cron == "" {
+		t.Fatalf("旧版 producthunt-daily 配置转换不符合预期: %+v", ph)
+	}
+	if _, ok := c.Channel(string(constants.AgentTypeRSS)); !ok {
+		t.Fatal("旧版 rss 配置未转换为渠道")
+	}
+	if len(c.Fetcher.ProductHunt.Channels) != 1 {
+		t.Fatalf("ProductHunt 默认渠道不符合预期: %v", c.Fetcher.ProductHunt.Channels)
+	}
+	bestBlogs := c.Fetcher.RSS[0]
+	if len(bestBlogs.Channels) != 1 || bestBlogs.Channels[0] != "rss" || bestBlogs.Formatter != string(constants.AgentBestBlogs) {
+		t.Fatalf("旧版 send 配置转换不符合预期: %+v", bestBlogs)
+	}
+	if other := c.Fetcher.RSS[1]; len(other.Channels) != 1 || other.Channels[0] != "ai" {
+		t.Fatalf("已声明的渠道不应被覆盖: %+v", other)
+	}
+}
+
+func TestCompileTemplates(t *testing.T) {
+	file := t.TempDir() + "/item.tmpl"
+	if err := os.WriteFile(file, []byte("[{{.Title}}]({{.Link}})"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := Config{
+		Channels: []ChannelConfig{{Name: "rss", AgentConfig: AgentConfig{Template: TemplateConfig{Text: "{{.Title}}"}}}},
+		Fetcher: FetcherConfig{
+			RSS: []RSSConfig{{Name: "blogs", Template: &TemplateConfig{File: file}}},
+		},
+	}
+	if err := c.compileTemplates(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Channels[0].Template.Compiled == nil || c.Fetcher.RSS[0].Template.Compiled == nil {
+		t.Fatal("模板未解析")
+	}
+
+	for _, text := range []string{"{{.Title", "{{.Missing}}", "{{.Title | unknown}}"} {
+		c := Config{Channels: []ChannelConfig{{Name: "rss", AgentConfig: AgentConfig{Template: TemplateConfig{Text: text}}}}}
+		if err := c.compileTemplates(); err == nil {
+			t.Fatalf("模板 %q 应校验失败", text)
+		}
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	for _, rule := range []FilterRule{
+		{Regex: "("},
+		{Mode: "xor", Keywords: []string{"a"}},
+		{Fields: []string{"body"}, Keywords: []string{"a"}},
+		{Name: "empty"},
+	} {
+		c := Config{Fetcher: FetcherConfig{RSS: []RSSConfig{{Name: "blogs", Filter: FilterConfig{Exclude: []FilterRule{rule}}}}}}
+		if err := c.validateFilters(); err == nil {
+			t.Fatalf("规则 %+v 应校验失败", rule)
+		}
+	}
+}
+
+func TestValidateFeedNames(t *testing.T) {
+	c := Config{Fetcher: FetcherConfig{RSS: []RSSConfig{{Name: "blogs"}, {Name: "hn"}}}}
+	if err := c.validateFeedNames(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []constants.AgentName{"health", "outbox", "digest", "blogs"} {
+		c.Fetcher.RSS = []RSSConfig{{Name: "blogs"}, {Name: name}}
+		if err := c.validateFeedNames(); err == nil {
+			t.Errorf("源名称 %s 应当报错", name)
+		}
+	}
+}
+
+func TestValidateScrapes(t *testing.T) {
+	c := Config{Fetcher: FetcherConfig{ProductHunt: ProductHuntConfig{Enabled: true}}}
+	c.Fetcher.ProductHunt.setDefaults()
+	if err := c.validateScrapes(); err != nil {
+		t.Fatalf("默认抓取规则应当有效: %v", err)
+	}
+	c.Fetcher.RSS = []RSSConfig{{Name: "page", Scrape: &ScrapeConfig{Item: "li", Fields: ScrapeFields{Title: FieldSelector{Selector: "a", Pattern: "("}}}}}
+	if err := c.validateScrapes(); err == nil {
+		t.Fatal("无效的正则表达式应当报错")
+	}
+}
+
+func TestLoadSubscriptions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "subscriptions.yaml")
+	content := "rss:\n  - name: hn\n    url: https://hnrss.org/frontpage\n    enabled: true\n    send: true\n    tags: [Tech]\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := Config{Fetcher: FetcherConfig{Subscriptions: file, RSS: []RSSConfig{{Name: "blogs"}}}}
+	if err := c.loadSubscriptions(); err != nil {
+		t.Fatal(err)
+	}
+	c.normalizeChannels()
+	if len(c.Fetcher.RSS) != 2 {
+		t.Fatalf("订阅文件中的源应当追加到 rss: %+v", c.Fetcher.RSS)
+	}
+	if hn := c.Fetcher.RSS[1]; hn.Name != "hn" || !hn.Enabled || len(hn.Tags) != 1 || len(hn.Channels) != 1 {
+		t.Fatalf("订阅文件中的源不符合预期: %+v", hn)
+	}
+
+	// 订阅文件不存在时忽略
+	c = Config{Fetcher: FetcherConfig{Subscriptions: filepath.Join(t.TempDir(), "missing.yaml")}}
+	if err := c.loadSubscriptions(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -13,3 +13,46 @@ const (
 	AgentTypePH  AgentType = "producthunt-daily"
 	AgentTypeRSS AgentType = "rss"
 )
+
+// 渠道类型
+const (
+	ChannelTypeFeishu   = "feishu"             // 飞书机器人，通用 RSS 格式
+	ChannelTypeFeishuPH = "feishu-producthunt" // 飞书机器人，ProductHunt 格式
+	ChannelTypeSlack    = "slack"              // Slack incoming webhook
+	ChannelTypeDingTalk = "dingtalk"           // 钉钉群机器人
+	ChannelTypeWeCom    = "wecom"              // 企业微信群机器人
+)
+
+// 飞书消息格式
+const (
+	FeishuFormatPost = "post" // 富文本
+	FeishuFormatCard = "card" // 交互式卡片
+)
+
+// 条目内容清洗格式
+const (
+	SanitizeText     = "text"     // 纯文本
+	SanitizeMarkdown = "markdown" // 轻量 markdown，保留链接、加粗和列表
+)
+
+// 定时发送没有新条目时的处理方式
+const (
+	NoUpdatesSkip   = "skip"   // 跳过，不发送
+	NoUpdatesNotify = "notify" // 发送“暂无更新”提示
+)
+
+// 过滤规则的组合方式
+const (
+	FilterModeAnd = "and" // 所有条件都满足
+	FilterModeOr  = "or"  // 任一条件满足
+)
+
+// 过滤规则匹配的字段
+const (
+	FilterFieldTitle       = "title"
+	FilterFieldSummary     = "summary"
+	FilterFieldDescription = "description"
+	FilterFieldAuthor      = "author"
+	FilterFieldLink        = "link"
+	FilterFieldCategory    = "category"
+)
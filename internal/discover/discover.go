@@ -0,0 +1,133 @@
+package discover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/weirwei/rss-agent/internal/fetcher"
+	"github.com/weirwei/rss-agent/internal/httpclient"
+)
+
+const (
+	SourceInput = "input" // 输入的地址本身就是源
+	SourceLink  = "link"  // 页面中 <link rel="alternate"> 声明的源
+	SourcePath  = "path"  // 常见的源路径
+)
+
+// defaultTimeout 验证单个候选源的时限
+const defaultTimeout = 15 * time.Second
+
+// commonPaths 常见的源路径，相对于站点根目录
+var commonPaths = []string{"/feed", "/rss", "/feed.xml", "/rss.xml", "/atom.xml", "/index.xml", "/feed.json"}
+
+// feedTypes <link rel="alternate"> 中表示源的类型
+var feedTypes = []string{"application/rss+xml", "application/atom+xml", "application/feed+json", "application/json", "application/rdf+xml"}
+
+// Candidate 候选源
+type Candidate struct {
+	URL    string
+	Source string // 发现方式，见 SourceLink
+	Title  string // 验证成功时为源的标题
+	Items  int    // 验证成功时为源的条目数
+	Err    error  // 验证失败的原因
+}
+
+// Valid 候选源是否能被 RSSFetcher 解析
+func (c Candidate) Valid() bool {
+	return c.Err == nil
+}
+
+// Discover 从网站地址发现源：输入地址本身、页面中声明的源和常见路径，
+// 每个候选源都用 RSSFetcher 验证，按发现顺序返回
+func Discover(ctx context.Context, client *httpclient.Client, siteURL string) ([]Candidate, error) {
+	if client == nil {
+		client = httpclient.New(nil, nil)
+	}
+	base, err := url.Parse(siteURL)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("无效的网站地址: %s", siteURL)
+	}
+
+	candidates := []Candidate{{URL: base.String(), Source: SourceInput}}
+	if body, err := client.Get(ctx, base.String()); err == nil {
+		for _, link := range alternateLinks(body, base) {
+			candidates = append(candidates, Candidate{URL: link, Source: SourceLink})
+		}
+	}
+	for _, path := range commonPaths {
+		candidates = append(candidates, Candidate{URL: base.ResolveReference(&url.URL{Path: path}).String(), Source: SourcePath})
+	}
+	candidates = dedupe(candidates)
+
+	rss := fetcher.NewRSSFetcher(client)
+	var wg sync.WaitGroup
+	for i := range candidates {
+		wg.Add(1)
+		go func(c *Candidate) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+			defer cancel()
+			data, err := rss.Fetch(ctx, c.URL)
+			if err != nil {
+				c.Err = err
+				return
+			}
+			c.Title = data.Title
+			c.Items = len(data.Items)
+		}(&candidates[i])
+	}
+	wg.Wait()
+	return candidates, nil
+}
+
+// alternateLinks 解析页面中声明的源地址
+func alternateLinks(body []byte, base *url.URL) []string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	// 页面可以通过 <base> 指定相对地址的基准
+	if href, ok := doc.Find("base[href]").First().Attr("href"); ok {
+		if u, err := base.Parse(href); err == nil {
+			base = u
+		}
+	}
+	var links []string
+	doc.Find(`link[rel~="alternate"][href]`).Each(func(_ int, s *goquery.Selection) {
+		typ := strings.ToLower(strings.TrimSpace(strings.Split(s.AttrOr("type", ""), ";")[0]))
+		if !isFeedType(typ) {
+			return
+		}
+		if u, err := base.Parse(strings.TrimSpace(s.AttrOr("href", ""))); err == nil {
+			links = append(links, u.String())
+		}
+	})
+	return links
+}
+
+func isFeedType(typ string) bool {
+	for _, t := range feedTypes {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+func dedupe(candidates []Candidate) []Candidate {
+	seen := make(map[string]bool)
+	result := candidates[:0]
+	for _, c := range candidates {
+		if !seen[c.URL] {
+			seen[c.URL] = true
+			result = append(result, c)
+		}
+	}
+	return result
+}
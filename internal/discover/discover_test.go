@@ -0,0 +1,56 @@
+package discover
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<html><head>
+<link rel="alternate" type="application/atom+xml" title="Atom" href="/blog/atom.xml">
+<link rel="alternate" type="text/html" hreflang="en" href="/en/">
+<link rel="stylesheet" href="/style.css">
+</head><body>home</body></html>`))
+	})
+	mux.HandleFunc("/blog/atom.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title>
+<entry><title>a</title><id>1</id><link href="https://example.com/a"/><updated>2024-09-30T08:00:00Z</updated></entry></feed>`))
+	})
+	mux.HandleFunc("/feed.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version":"https://jsonfeed.org/version/1.1","title":"JSON","items":[{"id":"1","url":"https://example.com/1"},{"id":"2","url":"https://example.com/2"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	candidates, err := Discover(context.Background(), nil, server.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := make(map[string]Candidate)
+	for _, c := range candidates {
+		if c.Valid() {
+			valid[c.URL] = c
+		}
+	}
+	if len(valid) != 2 {
+		t.Fatalf("应当发现两个源: %+v", candidates)
+	}
+	if c := valid[server.URL+"/blog/atom.xml"]; c.Source != SourceLink || c.Title != "Blog" || c.Items != 1 {
+		t.Fatalf("页面声明的源不符合预期: %+v", c)
+	}
+	if c := valid[server.URL+"/feed.json"]; c.Source != SourcePath || c.Title != "JSON" || c.Items != 2 {
+		t.Fatalf("常见路径的源不符合预期: %+v", c)
+	}
+
+	if _, err := Discover(context.Background(), nil, "not a url"); err == nil {
+		t.Fatal("无效的地址应当报错")
+	}
+}
@@ -1,11 +1,13 @@
 package fetcher
 
 import (
+	"context"
+
 	"github.com/weirwei/rss-agent/internal/model"
 )
 
 // FeedFetcher 定义了获取数据的统一接口
 type FeedFetcher interface {
-	Fetch(url string) (*model.FeedData, error)
+	Fetch(ctx context.Context, url string) (*model.FeedData, error)
 	Complete(data *model.FeedData) error
 }
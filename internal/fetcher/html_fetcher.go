@@ -0,0 +1,216 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/httpclient"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+// HTMLFetcher 按 CSS 选择器抓取 HTML 页面的获取器
+type HTMLFetcher struct {
+	agents   []agent.Agent
+	client   *httpclient.Client
+	cfg      config.ScrapeConfig
+	fields   map[string]*fieldSelector
+	location *time.Location
+}
+
+// fieldSelector 编译后的字段选择器
+type fieldSelector struct {
+	selector string
+	attr     string
+	pattern  *regexp.Regexp
+}
+
+// NewHTMLFetcher 创建 HTML 获取器，Complete 时将增量数据发送给所有 agents
+func NewHTMLFetcher(client *httpclient.Client, cfg config.ScrapeConfig, agents ...agent.Agent) (*HTMLFetcher, error) {
+	if client == nil {
+		client = httpclient.New(nil, nil)
+	}
+	if _, err := cascadia.ParseGroup(cfg.Item); err != nil {
+		return nil, fmt.Errorf("条目选择器无效 %s: %v", cfg.Item, err)
+	}
+	h := &HTMLFetcher{
+		agents:   agents,
+		client:   client,
+		cfg:      cfg,
+		fields:   make(map[string]*fieldSelector),
+		location: time.Local,
+	}
+	for name, field := range map[string]config.FieldSelector{
+		"title":       cfg.Fields.Title,
+		"link":        cfg.Fields.Link,
+		"summary":     cfg.Fields.Summary,
+		"description": cfg.Fields.Description,
+		"image":       cfg.Fields.Image,
+		"date":        cfg.Fields.Date,
+		"author":      cfg.Fields.Author,
+	} {
+		if field == (config.FieldSelector{}) {
+			continue
+		}
+		if field.Selector != "" {
+			if _, err := cascadia.ParseGroup(field.Selector); err != nil {
+				return nil, fmt.Errorf("字段 %s 的选择器无效 %s: %v", name, field.Selector, err)
+			}
+		}
+		compiled := &fieldSelector{selector: field.Selector, attr: field.Attr}
+		if field.Pattern != "" {
+			re, err := regexp.Compile(field.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("字段 %s 的正则表达式无效: %v", name, err)
+			}
+			compiled.pattern = re
+		}
+		h.fields[name] = compiled
+	}
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("时区无效: %v", err)
+		}
+		h.location = loc
+	}
+	return h, nil
+}
+
+// Fetch 实现 FeedFetcher 接口 - HTML 页面方式
+func (h *HTMLFetcher) Fetch(ctx context.Context, pageURL string) (*model.FeedData, error) {
+	body, err := h.client.Get(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML页面失败: %v", err)
+	}
+	base, _ := url.Parse(pageURL)
+
+	now := time.Now()
+	result := &model.FeedData{
+		Title:       h.cfg.Title,
+		Description: h.cfg.Description,
+		LastUpdated: now,
+		Items:       make([]model.FeedItem, 0),
+	}
+	if result.Title == "" {
+		result.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	}
+
+	items := doc.Find(h.cfg.Item)
+	if items.Length() == 0 {
+		return nil, fmt.Errorf("页面中没有匹配 %s 的条目，请检查抓取规则", h.cfg.Item)
+	}
+	items.Each(func(_ int, start *goquery.Selection) {
+		// 条目范围：起始元素及其后直到下一个条目的兄弟元素
+		scope := start.AddSelection(start.NextUntilSelection(items))
+		item := model.FeedItem{
+			Title:       h.value(scope, "title"),
+			Link:        resolveURL(base, h.value(scope, "link")),
+			Summary:     h.value(scope, "summary"),
+			Description: h.value(scope, "description"),
+			Image:       resolveURL(base, h.value(scope, "image")),
+			Author:      h.value(scope, "author"),
+			Published:   h.date(scope, now),
+		}
+		item.Updated = item.Published
+		if item.Title == "" && item.Link == "" {
+			return
+		}
+		result.Items = append(result.Items, item)
+	})
+	if len(result.Items) == 0 {
+		return nil, fmt.Errorf("页面中 %d 个条目均未取到标题和链接，请检查抓取规则", items.Length())
+	}
+	return result, nil
+}
+
+// value 在条目范围内按字段选择器取值
+func (h *HTMLFetcher) value(scope *goquery.Selection, name string) string {
+	field, ok := h.fields[name]
+	if !ok {
+		return ""
+	}
+	matched := scope.First()
+	if field.selector != "" {
+		matched = scope.Filter(field.selector).AddSelection(scope.Find(field.selector))
+	}
+	for i := range matched.Nodes {
+		if v := field.extract(matched.Eq(i)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (f *fieldSelector) extract(s *goquery.Selection) string {
+	var v string
+	switch f.attr {
+	case "":
+		v = s.Text()
+	case "html":
+		v, _ = s.Html()
+	default:
+		v, _ = s.Attr(f.attr)
+	}
+	if f.pattern != nil {
+		match := f.pattern.FindStringSubmatch(v)
+		switch {
+		case match == nil:
+			return ""
+		case len(match) > 1:
+			v = match[1]
+		default:
+			v = match[0]
+		}
+	}
+	if f.attr == "html" {
+		v = html.UnescapeString(v)
+	}
+	return strings.TrimSpace(v)
+}
+
+// date 解析日期字段，未配置或解析失败时使用抓取时间
+func (h *HTMLFetcher) date(scope *goquery.Selection, now time.Time) time.Time {
+	v := h.value(scope, "date")
+	if v == "" {
+		return now
+	}
+	layout := h.cfg.DateFormat
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	t, err := time.ParseInLocation(layout, v, h.location)
+	if err != nil {
+		return now
+	}
+	return t
+}
+
+// resolveURL 将相对地址转换为绝对地址
+func resolveURL(base *url.URL, ref string) string {
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+func (h *HTMLFetcher) Complete(data *model.FeedData) error {
+	return sendAll(h.agents, data)
+}
@@ -0,0 +1,98 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+)
+
+func TestHTMLFetcherFlatLayout(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "producthunt.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	h, err := NewHTMLFetcher(nil, config.ScrapeConfig{
+		Item: "h2:has(a)",
+		Fields: config.ScrapeFields{
+			Title:       config.FieldSelector{Selector: "a", Pattern: `^(?:\d+\.\s*)?(.+)$`},
+			Link:        config.FieldSelector{Selector: "a", Attr: "href"},
+			Summary:     config.FieldSelector{Selector: "p", Attr: "html", Pattern: `<strong>标语</strong>：([^<]+)`},
+			Description: config.FieldSelector{Selector: "p", Attr: "html", Pattern: `<strong>介绍</strong>：([^<]+)`},
+			Image:       config.FieldSelector{Selector: "img", Attr: "src"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := h.Fetch(context.Background(), server.URL+"/daily/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Title != "PH 今日热榜" || len(data.Items) != 3 {
+		t.Fatalf("源不符合预期: %+v", data)
+	}
+	alpha, beta, gamma := data.Items[0], data.Items[1], data.Items[2]
+	if alpha.Title != "Alpha" || alpha.Summary != "Alpha 标语" || alpha.Description != "Alpha 介绍 & 更多" || alpha.Image != server.URL+"/images/alpha.png" {
+		t.Fatalf("第一个条目不符合预期: %+v", alpha)
+	}
+	// 缺少标语的条目不影响后面的条目
+	if beta.Title != "Beta" || beta.Summary != "" || beta.Description != "Beta 介绍" || beta.Image != "" {
+		t.Fatalf("缺少标语的条目不符合预期: %+v", beta)
+	}
+	if gamma.Title != "Gamma" || gamma.Link != "https://www.producthunt.com/posts/gamma" || gamma.Summary != "Gamma 标语" {
+		t.Fatalf("最后一个条目不符合预期: %+v", gamma)
+	}
+}
+
+func TestHTMLFetcherContainerLayout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<ul>
+<li class="post"><a class="title" href="/p/1">One</a><time datetime="2024-09-30 08:00">昨天</time><span class="by">Alice</span></li>
+<li class="post"><a class="title" href="/p/2">Two</a></li>
+<li class="post"><span>没有标题和链接</span></li>
+</ul>`))
+	}))
+	defer server.Close()
+
+	h, err := NewHTMLFetcher(nil, config.ScrapeConfig{
+		Title: "Posts",
+		Item:  "li.post",
+		Fields: config.ScrapeFields{
+			Title:  config.FieldSelector{Selector: "a.title"},
+			Link:   config.FieldSelector{Selector: "a.title", Attr: "href"},
+			Date:   config.FieldSelector{Selector: "time", Attr: "datetime"},
+			Author: config.FieldSelector{Selector: ".by"},
+		},
+		DateFormat: "2006-01-02 15:04",
+		Timezone:   "Asia/Shanghai",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := h.Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Title != "Posts" || len(data.Items) != 2 {
+		t.Fatalf("源不符合预期: %+v", data)
+	}
+	one := data.Items[0]
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	if one.Link != server.URL+"/p/1" || one.Author != "Alice" || !one.Published.Equal(time.Date(2024, 9, 30, 8, 0, 0, 0, loc)) {
+		t.Fatalf("第一个条目不符合预期: %+v", one)
+	}
+	if data.Items[1].Published.IsZero() || data.Items[1].Author != "" {
+		t.Fatalf("缺少日期的条目不符合预期: %+v", data.Items[1])
+	}
+}
@@ -1,31 +1,46 @@
 package fetcher
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
 	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/httpclient"
 	"github.com/weirwei/rss-agent/internal/model"
 )
 
 // RSSFetcher RSS源获取器
 type RSSFetcher struct {
-	agent  agent.Agent
+	agents []agent.Agent
+	client *httpclient.Client
 	parser *gofeed.Parser
 }
 
-// NewRSSFetcher 创建RSS获取器
-func NewRSSFetcher(agent agent.Agent) *RSSFetcher {
+// NewRSSFetcher 创建RSS获取器，Complete 时将增量数据发送给所有 agents
+func NewRSSFetcher(client *httpclient.Client, agents ...agent.Agent) *RSSFetcher {
+	if client == nil {
+		client = httpclient.New(nil, nil)
+	}
 	return &RSSFetcher{
 		parser: gofeed.NewParser(),
-		agent:  agent,
+		client: client,
+		agents: agents,
 	}
 }
 
 // Fetch 实现 FeedFetcher 接口 - RSS方式
-func (r *RSSFetcher) Fetch(url string) (*model.FeedData, error) {
-	feed, err := r.parser.ParseURL(url)
+func (r *RSSFetcher) Fetch(ctx context.Context, url string) (*model.FeedData, error) {
+	body, err := r.client.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := r.parser.Parse(bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("解析RSS源失败: %v", err)
 	}
@@ -41,30 +56,100 @@ func (r *RSSFetcher) Fetch(url string) (*model.FeedData, error) {
 	}
 
 	for _, item := range feed.Items {
-		pubTime := time.Now()
-		if item.PublishedParsed != nil {
-			pubTime = *item.PublishedParsed
+		result.Items = append(result.Items, convertItem(feed, item))
+	}
+
+	return result, nil
+}
+
+// convertItem 转换条目。发布时间缺失时使用更新时间，两者都缺失时使用当前时间；
+// 条目没有作者时使用源的作者，没有图片时使用第一个图片附件
+func convertItem(feed *gofeed.Feed, item *gofeed.Item) model.FeedItem {
+	feedItem := model.FeedItem{
+		GUID:        item.GUID,
+		Title:       item.Title,
+		Link:        item.Link,
+		Summary:     item.Description,
+		Description: item.Content,
+		Categories:  item.Categories,
+	}
+
+	switch {
+	case item.PublishedParsed != nil:
+		feedItem.Published = *item.PublishedParsed
+	case item.UpdatedParsed != nil:
+		feedItem.Published = *item.UpdatedParsed
+	default:
+		feedItem.Published = time.Now()
+	}
+	feedItem.Updated = feedItem.Published
+	if item.UpdatedParsed != nil {
+		feedItem.Updated = *item.UpdatedParsed
+	}
+
+	authors := item.Authors
+	if len(authors) == 0 && item.Author != nil {
+		authors = []*gofeed.Person{item.Author}
+	}
+	if len(authors) == 0 {
+		authors = feed.Authors
+	}
+	for _, author := range authors {
+		if author != nil && (author.Name != "" || author.Email != "") {
+			feedItem.Authors = append(feedItem.Authors, model.Person{Name: author.Name, Email: author.Email})
+		}
+	}
+	if len(feedItem.Authors) > 0 {
+		feedItem.Author = feedItem.Authors[0].Name
+		if feedItem.Author == "" {
+			feedItem.Author = feedItem.Authors[0].Email
 		}
+	}
 
-		feedItem := model.FeedItem{
-			Title:       item.Title,
-			Link:        item.Link,
-			Published:   pubTime,
-			Summary:     item.Description,
-			Description: item.Content,
+	for _, enclosure := range item.Enclosures {
+		if enclosure == nil || enclosure.URL == "" {
+			continue
 		}
-		if item.Author != nil {
-			feedItem.Author = item.Author.Name
+		length, _ := strconv.ParseInt(enclosure.Length, 10, 64)
+		// gofeed 将 JSON Feed 附件的时长写入了 Length，不能当作字节数
+		if feed.FeedType == "json" {
+			length = 0
+		}
+		feedItem.Enclosures = append(feedItem.Enclosures, model.Enclosure{URL: enclosure.URL, Type: enclosure.Type, Length: length})
+	}
+	if item.Image != nil {
+		feedItem.Image = item.Image.URL
+	}
+	if feedItem.Image == "" {
+		for _, enclosure := range feedItem.Enclosures {
+			if strings.HasPrefix(enclosure.Type, "image/") {
+				feedItem.Image = enclosure.URL
+				break
+			}
 		}
-		result.Items = append(result.Items, feedItem)
 	}
 
-	return result, nil
+	feedItem.Extensions = convertExtensions(item.Extensions)
+	return feedItem
 }
 
-func (r *RSSFetcher) Complete(data *model.FeedData) error {
-	if data == nil || r.agent == nil {
+// convertExtensions 将命名空间扩展展平为 "前缀:名称" 的形式，忽略子元素
+func convertExtensions(extensions ext.Extensions) map[string][]model.Extension {
+	if len(extensions) == 0 {
 		return nil
 	}
-	return r.agent.Send(*data)
+	result := make(map[string][]model.Extension)
+	for prefix, elements := range extensions {
+		for name, values := range elements {
+			key := prefix + ":" + name
+			for _, v := range values {
+				result[key] = append(result[key], model.Extension{Value: strings.TrimSpace(v.Value), Attrs: v.Attrs})
+			}
+		}
+	}
+	return result
+}
+
+func (r *RSSFetcher) Complete(data *model.FeedData) error {
+	return sendAll(r.agents, data)
 }
@@ -0,0 +1,143 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestRSSFetcherFixtures(t *testing.T) {
+	date := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		fixture string
+		title   string
+		check   func(t *testing.T, items []model.FeedItem)
+	}{
+		{
+			fixture: "rss2.xml",
+			title:   "RSS 2.0 示例",
+			check: func(t *testing.T, items []model.FeedItem) {
+				item := items[0]
+				if item.GUID != "rss-1" || item.Author != "Alice" || item.Description != "<p>正文一</p>" {
+					t.Fatalf("基本字段不符合预期: %+v", item)
+				}
+				if !item.Published.Equal(date("2024-09-30T08:00:00Z")) || !item.Updated.Equal(item.Published) {
+					t.Fatalf("时间不符合预期: %v %v", item.Published, item.Updated)
+				}
+				if strings.Join(item.Categories, ",") != "Go,RSS" {
+					t.Fatalf("分类不符合预期: %v", item.Categories)
+				}
+				if len(item.Enclosures) != 2 || item.Enclosures[0].Length != 1024 || item.Enclosures[0].Type != "audio/mpeg" {
+					t.Fatalf("附件不符合预期: %+v", item.Enclosures)
+				}
+				if item.Image != "https://example.com/1.jpg" {
+					t.Fatalf("图片不符合预期: %s", item.Image)
+				}
+				thumbs := item.Extensions["media:thumbnail"]
+				if len(thumbs) != 1 || thumbs[0].Attrs["url"] != "https://example.com/1-thumb.jpg" {
+					t.Fatalf("扩展不符合预期: %+v", item.Extensions)
+				}
+				// 没有日期的条目使用抓取时间
+				if items[1].Published.IsZero() || items[1].GUID != "" {
+					t.Fatalf("缺失日期处理不符合预期: %+v", items[1])
+				}
+			},
+		},
+		{
+			fixture: "atom.xml",
+			title:   "Atom 1.0 示例",
+			check: func(t *testing.T, items []model.FeedItem) {
+				item := items[0]
+				if item.GUID != "urn:uuid:entry-1" || item.Summary != "摘要一" || item.Description != "<p>正文一</p>" {
+					t.Fatalf("基本字段不符合预期: %+v", item)
+				}
+				if !item.Published.Equal(date("2024-09-30T08:00:00Z")) || !item.Updated.Equal(date("2024-10-01T09:00:00Z")) {
+					t.Fatalf("时间不符合预期: %v %v", item.Published, item.Updated)
+				}
+				if len(item.Authors) != 2 || item.Author != "Bob" || item.Authors[0].Email != "bob@example.org" || item.Authors[1].Name != "Carol" {
+					t.Fatalf("作者不符合预期: %+v", item.Authors)
+				}
+				if strings.Join(item.Categories, ",") != "Atom" || item.Image != "https://example.org/1.png" {
+					t.Fatalf("分类或图片不符合预期: %+v", item)
+				}
+				// 只有更新时间的条目以更新时间作为发布时间，没有作者时使用源的作者
+				if !items[1].Published.Equal(date("2024-09-29T12:00:00Z")) || items[1].Author != "Feed Author" {
+					t.Fatalf("只有更新时间的条目不符合预期: %+v", items[1])
+				}
+			},
+		},
+		{
+			fixture: "jsonfeed.json",
+			title:   "JSON Feed 1.1 示例",
+			check: func(t *testing.T, items []model.FeedItem) {
+				item := items[0]
+				if item.GUID != "json-1" || item.Link != "https://example.net/1" || item.Image != "https://example.net/1.png" {
+					t.Fatalf("基本字段不符合预期: %+v", item)
+				}
+				if !item.Published.Equal(date("2024-09-30T08:00:00Z")) || !item.Updated.Equal(date("2024-10-01T09:00:00Z")) {
+					t.Fatalf("时间不符合预期: %v %v", item.Published, item.Updated)
+				}
+				if len(item.Authors) != 2 || item.Author != "Dave" || strings.Join(item.Categories, ",") != "JSON,Feed" {
+					t.Fatalf("作者或分类不符合预期: %+v", item)
+				}
+				if len(item.Enclosures) != 1 || item.Enclosures[0].Type != "audio/mpeg" {
+					t.Fatalf("附件不符合预期: %+v", item.Enclosures)
+				}
+				if items[1].Author != "Feed Author" {
+					t.Fatalf("源作者继承不符合预期: %+v", items[1])
+				}
+			},
+		},
+		{
+			fixture: "rdf.xml",
+			title:   "RSS 1.0 示例",
+			check: func(t *testing.T, items []model.FeedItem) {
+				item := items[0]
+				if item.Link != "https://example.com/rdf/1" || item.Summary != "摘要一" || item.Author != "Frank" {
+					t.Fatalf("基本字段不符合预期: %+v", item)
+				}
+				if !item.Published.Equal(date("2024-09-30T08:00:00Z")) {
+					t.Fatalf("时间不符合预期: %v", item.Published)
+				}
+				if len(item.Extensions["dc:subject"]) != 1 || item.Extensions["dc:subject"][0].Value != "RDF" {
+					t.Fatalf("扩展不符合预期: %+v", item.Extensions)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(content)
+			}))
+			defer server.Close()
+
+			data, err := NewRSSFetcher(nil).Fetch(context.Background(), server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data.Title != tt.title || len(data.Items) == 0 {
+				t.Fatalf("源不符合预期: %+v", data)
+			}
+			tt.check(t, data.Items)
+		})
+	}
+}
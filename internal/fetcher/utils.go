@@ -1,28 +1,24 @@
 package fetcher
 
-import "github.com/weirwei/rss-agent/internal/model"
+import (
+	"errors"
 
-// LatestFeed 获取最新的文章
-func LatestFeed(oldFeed, newFeed model.FeedData) model.FeedData {
-	if len(oldFeed.Items) == 0 {
-		return newFeed
-	}
-	repeatM := make(map[string]bool)
-	for _, v := range oldFeed.Items {
-		repeatM[v.Title] = true
-	}
-	latestFeedData := model.FeedData{
-		Title:       newFeed.Title,
-		Description: newFeed.Description,
-		LastUpdated: newFeed.LastUpdated,
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+// sendAll 将数据发送给所有代理，汇总发送失败的错误
+func sendAll(agents []agent.Agent, data *model.FeedData) error {
+	if data == nil {
+		return nil
 	}
-	for _, v := range newFeed.Items {
-		if repeatM[v.Title] {
-			break
+	var errs []error
+	for _, ag := range agents {
+		if err := ag.Send(*data); err != nil {
+			errs = append(errs, err)
 		}
-		latestFeedData.Items = append(latestFeedData.Items, v)
 	}
-	return latestFeedData
+	return errors.Join(errs...)
 }
 
 // itemsEqual 比较两个 FeedItem 是否相等
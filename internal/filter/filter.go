@@ -0,0 +1,260 @@
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+// defaultFields 关键词和正则默认匹配的字段
+var defaultFields = []string{constants.FilterFieldTitle, constants.FilterFieldSummary}
+
+// Filter 源的条目过滤器
+type Filter struct {
+	include []*Rule
+	exclude []*Rule
+}
+
+// Stat 单条规则在一次过滤中丢弃的条目数
+type Stat struct {
+	Rule    string
+	Dropped int
+}
+
+// Rule 编译后的过滤规则
+type Rule struct {
+	name      string
+	and       bool
+	fields    []string
+	keywords  []string
+	regex     *regexp.Regexp
+	authors   []string
+	domains   []string
+	olderThan time.Duration
+	newerThan time.Duration
+}
+
+// New 编译过滤规则，没有配置任何规则时返回 nil
+func New(cfg config.FilterConfig) (*Filter, error) {
+	if len(cfg.Include) == 0 && len(cfg.Exclude) == 0 {
+		return nil, nil
+	}
+	f := &Filter{}
+	for i, r := range cfg.Include {
+		compiled, err := NewRule(r, fmt.Sprintf("include-%d", i+1))
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, compiled)
+	}
+	for i, r := range cfg.Exclude {
+		compiled, err := NewRule(r, fmt.Sprintf("exclude-%d", i+1))
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, compiled)
+	}
+	return f, nil
+}
+
+// NewRule 编译单条规则，规则未命名时使用 defaultName
+func NewRule(cfg config.FilterRule, defaultName string) (*Rule, error) {
+	r := &Rule{
+		name:      cfg.Name,
+		and:       cfg.Mode == constants.FilterModeAnd,
+		fields:    cfg.Fields,
+		olderThan: time.Duration(cfg.OlderThan) * time.Hour,
+		newerThan: time.Duration(cfg.NewerThan) * time.Hour,
+	}
+	if r.name == "" {
+		r.name = defaultName
+	}
+	if len(r.fields) == 0 {
+		r.fields = defaultFields
+	}
+	for _, keyword := range cfg.Keywords {
+		r.keywords = append(r.keywords, strings.ToLower(keyword))
+	}
+	for _, author := range cfg.Authors {
+		r.authors = append(r.authors, strings.ToLower(author))
+	}
+	for _, domain := range cfg.Domains {
+		r.domains = append(r.domains, strings.ToLower(strings.TrimPrefix(domain, ".")))
+	}
+	if cfg.Regex != "" {
+		re, err := regexp.Compile(cfg.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("规则 %s 的正则表达式无效: %v", r.name, err)
+		}
+		r.regex = re
+	}
+	return r, nil
+}
+
+// Apply 过滤条目，返回保留的条目和每条规则丢弃的条目数。
+// 未匹配任何 include 规则的条目计入 "include"，匹配多条 exclude 规则的条目只计入第一条
+func (f *Filter) Apply(items []model.FeedItem, now time.Time) ([]model.FeedItem, []Stat) {
+	if f == nil {
+		return items, nil
+	}
+	var stats []Stat
+	if len(f.include) > 0 {
+		stats = append(stats, Stat{Rule: "include"})
+	}
+	for _, r := range f.exclude {
+		stats = append(stats, Stat{Rule: r.name})
+	}
+	offset := len(stats) - len(f.exclude)
+
+	kept := make([]model.FeedItem, 0, len(items))
+	for _, item := range items {
+		if len(f.include) > 0 && !matchAny(f.include, item, now) {
+			stats[0].Dropped++
+			continue
+		}
+		dropped := false
+		for i, r := range f.exclude {
+			if r.Match(item, now) {
+				stats[offset+i].Dropped++
+				dropped = true
+				break
+			}
+		}
+		if !dropped {
+			kept = append(kept, item)
+		}
+	}
+	return kept, stats
+}
+
+// Name 返回规则名称
+func (r *Rule) Name() string {
+	return r.name
+}
+
+func matchAny(rules []*Rule, item model.FeedItem, now time.Time) bool {
+	for _, r := range rules {
+		if r.Match(item, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match 按组合方式判断条目是否满足规则的条件
+func (r *Rule) Match(item model.FeedItem, now time.Time) bool {
+	var conditions []bool
+	texts := r.texts(item)
+	for _, keyword := range r.keywords {
+		conditions = append(conditions, containsAny(texts, keyword))
+	}
+	if r.regex != nil {
+		matched := false
+		for _, text := range texts {
+			if r.regex.MatchString(text) {
+				matched = true
+				break
+			}
+		}
+		conditions = append(conditions, matched)
+	}
+	if len(r.authors) > 0 {
+		conditions = append(conditions, matchAuthor(r.authors, item))
+	}
+	if len(r.domains) > 0 {
+		conditions = append(conditions, matchDomain(r.domains, item.Link))
+	}
+	if r.olderThan > 0 {
+		conditions = append(conditions, !item.Published.IsZero() && item.Published.Before(now.Add(-r.olderThan)))
+	}
+	if r.newerThan > 0 {
+		conditions = append(conditions, !item.Published.IsZero() && item.Published.After(now.Add(-r.newerThan)))
+	}
+
+	for _, ok := range conditions {
+		if r.and && !ok {
+			return false
+		}
+		if !r.and && ok {
+			return true
+		}
+	}
+	return r.and && len(conditions) > 0
+}
+
+// texts 返回关键词和正则匹配的字段内容
+func (r *Rule) texts(item model.FeedItem) []string {
+	texts := make([]string, 0, len(r.fields))
+	for _, field := range r.fields {
+		switch field {
+		case constants.FilterFieldTitle:
+			texts = append(texts, item.Title)
+		case constants.FilterFieldSummary:
+			texts = append(texts, item.Summary)
+		case constants.FilterFieldDescription:
+			texts = append(texts, item.Description)
+		case constants.FilterFieldAuthor:
+			texts = append(texts, item.Author)
+			for _, author := range item.Authors {
+				texts = append(texts, author.Name)
+			}
+		case constants.FilterFieldLink:
+			texts = append(texts, item.Link)
+		case constants.FilterFieldCategory:
+			texts = append(texts, item.Categories...)
+		}
+	}
+	return texts
+}
+
+func containsAny(texts []string, keyword string) bool {
+	for _, text := range texts {
+		if strings.Contains(strings.ToLower(text), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalsAny(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAuthor 条目的任一作者在列表中
+func matchAuthor(authors []string, item model.FeedItem) bool {
+	if equalsAny(authors, strings.ToLower(strings.TrimSpace(item.Author))) {
+		return true
+	}
+	for _, author := range item.Authors {
+		if equalsAny(authors, strings.ToLower(strings.TrimSpace(author.Name))) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDomain 链接的域名等于其中任一个或是其子域名
+func matchDomain(domains []string, link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
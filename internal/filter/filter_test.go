@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestApply(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	items := []model.FeedItem{
+		{GUID: "1", Title: "Go generics deep dive", Author: "Alice", Link: "https://blog.golang.org/a", Published: now.Add(-time.Hour)},
+		{GUID: "2", Title: "GO and NFT", Summary: "crypto", Link: "https://example.com/b", Published: now.Add(-time.Hour)},
+		{GUID: "3", Title: "Rust release", Author: "bob", Link: "https://rust-lang.org/c", Published: now.Add(-time.Hour)},
+		{GUID: "4", Title: "Old go post", Link: "https://example.com/d", Published: now.Add(-100 * time.Hour)},
+		{GUID: "5", Title: "Python tips", Link: "https://news.example.com/e", Published: now.Add(-time.Hour)},
+	}
+	f, err := New(config.FilterConfig{
+		Include: []config.FilterRule{
+			{Name: "go", Keywords: []string{"go"}},
+			{Name: "rust-by-bob", Mode: "and", Keywords: []string{"rust"}, Authors: []string{"Bob"}},
+			{Name: "example", Domains: []string{"example.com"}, Regex: `(?i)^python`, Mode: "and"},
+		},
+		Exclude: []config.FilterRule{
+			{Name: "crypto", Fields: []string{"summary"}, Regex: `crypto|web3`},
+			{Name: "old", OlderThan: 72},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, stats := f.Apply(items, now)
+
+	var guids []string
+	for _, item := range kept {
+		guids = append(guids, item.GUID)
+	}
+	if len(guids) != 3 || guids[0] != "1" || guids[1] != "3" || guids[2] != "5" {
+		t.Fatalf("保留的条目不符合预期: %v", guids)
+	}
+	want := map[string]int{"include": 0, "crypto": 1, "old": 1}
+	if len(stats) != len(want) {
+		t.Fatalf("统计不符合预期: %+v", stats)
+	}
+	for _, stat := range stats {
+		if want[stat.Rule] != stat.Dropped {
+			t.Fatalf("规则 %s 期望丢弃 %d 条，实际 %d", stat.Rule, want[stat.Rule], stat.Dropped)
+		}
+	}
+}
+
+func TestApplyIncludeOnly(t *testing.T) {
+	f, err := New(config.FilterConfig{Include: []config.FilterRule{{Keywords: []string{"AI"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, stats := f.Apply([]model.FeedItem{{Title: "ai news"}, {Title: "cooking"}}, time.Now())
+	if len(kept) != 1 || stats[0].Dropped != 1 {
+		t.Fatalf("过滤结果不符合预期: %+v %+v", kept, stats)
+	}
+
+	var none *Filter
+	if kept, _ := none.Apply([]model.FeedItem{{Title: "a"}}, time.Now()); len(kept) != 1 {
+		t.Fatal("未配置规则时不应过滤")
+	}
+}
+
+func TestRuleMatchMetadata(t *testing.T) {
+	item := model.FeedItem{
+		Title:      "Release notes",
+		Author:     "Alice",
+		Authors:    []model.Person{{Name: "Alice"}, {Name: "Bob"}},
+		Categories: []string{"Kubernetes", "Release"},
+	}
+	byCategory, err := NewRule(config.FilterRule{Keywords: []string{"kubernetes"}, Fields: []string{constants.FilterFieldCategory}}, "category")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byAuthor, err := NewRule(config.FilterRule{Authors: []string{"bob"}}, "author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !byCategory.Match(item, time.Now()) || !byAuthor.Match(item, time.Now()) {
+		t.Fatal("分类和第二作者应当匹配")
+	}
+}
@@ -0,0 +1,131 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+// ErrNotModified 服务端返回 304，内容没有变化
+var ErrNotModified = errors.New("内容未变化")
+
+// Stats 条件请求命中统计
+type Stats struct {
+	Hits   int // 304 未变化
+	Misses int // 完整下载
+}
+
+// Client 抓取器共享的 HTTP 层，按 URL 保存 ETag/Last-Modified 并发送条件请求
+type Client struct {
+	client     *http.Client
+	validators store.ValidatorStore
+
+	mu    sync.Mutex
+	stats map[string]*Stats
+}
+
+// New 创建共享 HTTP 层，validators 为空时不发送条件请求
+func New(client *http.Client, validators store.ValidatorStore) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{
+		client:     client,
+		validators: validators,
+		stats:      make(map[string]*Stats),
+	}
+}
+
+// Get 获取 URL 内容，服务端返回 304 时返回 ErrNotModified。收到 200 时即保存校验信息，
+// 调用方解析或处理内容失败时需调用 Forget 清除
+func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	if c.validators != nil {
+		v, err := c.validators.Validators(url)
+		if err != nil {
+			log.Error("读取校验信息失败 %s: %v", url, err)
+		}
+		if v.ETag != "" {
+			req.Header.Set("If-None-Match", v.ETag)
+		}
+		if v.LastModified != "" {
+			req.Header.Set("If-Modified-Since", v.LastModified)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取页面失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		c.record(url, true)
+		return nil, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP状态码错误: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取页面内容失败: %v", err)
+	}
+	c.record(url, false)
+
+	if c.validators != nil {
+		v := store.Validators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}
+		if err := c.validators.SaveValidators(url, v); err != nil {
+			log.Error("保存校验信息失败 %s: %v", url, err)
+		}
+	}
+	return body, nil
+}
+
+// Forget 清除 URL 的校验信息，下次请求将完整下载
+func (c *Client) Forget(url string) {
+	if c.validators == nil {
+		return
+	}
+	if err := c.validators.SaveValidators(url, store.Validators{}); err != nil {
+		log.Error("清除校验信息失败 %s: %v", url, err)
+	}
+}
+
+// Stats 返回 URL 的条件请求命中统计
+func (c *Client) Stats(url string) Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if s, ok := c.stats[url]; ok {
+		return *s
+	}
+	return Stats{}
+}
+
+func (c *Client) record(url string, hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.stats[url]
+	if !ok {
+		s = &Stats{}
+		c.stats[url] = s
+	}
+	if hit {
+		s.Hits++
+	} else {
+		s.Misses++
+	}
+}
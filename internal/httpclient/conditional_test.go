@@ -0,0 +1,40 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+func TestConditionalGet(t *testing.T) {
+	const etag = `"v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	c := New(srv.Client(), store.NewJSONStore(t.TempDir()))
+	body, err := c.Get(context.Background(), srv.URL)
+	if err != nil || string(body) != "hello" {
+		t.Fatalf("首次请求不符合预期: %q, %v", body, err)
+	}
+	if _, err := c.Get(context.Background(), srv.URL); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("期望 ErrNotModified，实际 %v", err)
+	}
+	c.Forget(srv.URL)
+	if _, err := c.Get(context.Background(), srv.URL); err != nil {
+		t.Fatalf("清除校验信息后应完整下载: %v", err)
+	}
+	if stats := c.Stats(srv.URL); stats.Hits != 1 || stats.Misses != 2 {
+		t.Fatalf("统计不符合预期: %+v", stats)
+	}
+}
@@ -0,0 +1,133 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+)
+
+const (
+	defaultTimeout     = 30 * time.Second
+	defaultMaxBodySize = 10 << 20
+	defaultUserAgent   = "rss-agent/1.0"
+)
+
+// NewHTTPClient 根据配置创建 HTTP 客户端，供所有抓取器和代理共享
+func NewHTTPClient(cfg config.HTTPConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// 未手动设置 Accept-Encoding 时由 Transport 自动请求并解压 gzip
+	transport.DisableCompression = false
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("解析代理地址失败: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.TLS.InsecureSkipVerify}
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("解析 CA 证书失败: %s", cfg.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	timeout := defaultTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+	maxBodySize := cfg.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &roundTripper{
+			next:        transport,
+			userAgent:   userAgent,
+			hosts:       cfg.Hosts,
+			maxBodySize: maxBodySize,
+		},
+	}, nil
+}
+
+// roundTripper 为每个请求附加 User-Agent、域名级请求头和 Cookie，并限制响应体大小
+type roundTripper struct {
+	next        http.RoundTripper
+	userAgent   string
+	hosts       map[string]config.HostConfig
+	maxBodySize int64
+}
+
+func (t *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	if host, ok := t.hosts[strings.ToLower(req.URL.Hostname())]; ok {
+		for k, v := range host.Headers {
+			req.Header.Set(k, v)
+		}
+		for _, c := range host.Cookies {
+			req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		remaining:  t.maxBodySize,
+	}
+	return resp, nil
+}
+
+// limitedBody 超过大小上限时返回错误，而不是静默截断
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// 探测是否还有剩余内容
+		var probe [1]byte
+		n, err := b.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("响应体超过大小上限")
+		}
+		return 0, err
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,50 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/config"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("session")
+		if r.Header.Get("User-Agent") != "test-agent" || r.Header.Get("X-Token") != "token" || cookie == nil || cookie.Value != "abc" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	client, err := NewHTTPClient(config.HTTPConfig{
+		UserAgent:   "test-agent",
+		MaxBodySize: 10,
+		Hosts: map[string]config.HostConfig{
+			u.Hostname(): {
+				Headers: map[string]string{"x-token": "token"},
+				Cookies: []config.CookieConfig{{Name: "session", Value: "abc"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("请求头未生效: %d", resp.StatusCode)
+	}
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Fatal("期望响应体超过上限时返回错误")
+	}
+}
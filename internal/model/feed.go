@@ -1,9 +1,14 @@
 package model
 
-import "time"
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"time"
+)
 
 // FeedData 统一的数据结构
 type FeedData struct {
+	Feed        string     `json:"feed,omitempty"` // 源名称
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
 	LastUpdated time.Time  `json:"last_updated"`
@@ -12,10 +17,48 @@ type FeedData struct {
 
 // FeedItem 统一的条目结构
 type FeedItem struct {
-	Title       string    `json:"title"`
-	Link        string    `json:"link"`
-	Published   time.Time `json:"published"`
-	Summary     string    `json:"summary"`     // 标语/简短描述
-	Description string    `json:"description"` // 详细描述
-	Author      string    `json:"author,omitempty"`
+	GUID        string                 `json:"guid,omitempty"`
+	Title       string                 `json:"title"`
+	Link        string                 `json:"link"`
+	Published   time.Time              `json:"published"`
+	Updated     time.Time              `json:"updated"`     // 最后更新时间，源未提供时与 Published 相同
+	Summary     string                 `json:"summary"`     // 标语/简短描述
+	Description string                 `json:"description"` // 详细描述
+	Author      string                 `json:"author,omitempty"`
+	Authors     []Person               `json:"authors,omitempty"` // 全部作者，Author 为第一个作者的名字
+	Image       string                 `json:"image,omitempty"`   // 封面图片地址
+	Categories  []string               `json:"categories,omitempty"`
+	Enclosures  []Enclosure            `json:"enclosures,omitempty"`
+	Extensions  map[string][]Extension `json:"extensions,omitempty"` // 命名空间扩展，键为 "前缀:名称"，如 "media:thumbnail"
+}
+
+// Person 作者
+type Person struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// Enclosure 附件，如播客音频、图片
+type Enclosure struct {
+	URL    string `json:"url"`
+	Type   string `json:"type,omitempty"`
+	Length int64  `json:"length,omitempty"`
+}
+
+// Extension 命名空间扩展元素
+type Extension struct {
+	Value string            `json:"value,omitempty"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// Key 返回条目的唯一标识：优先使用 GUID，其次是链接，最后是内容哈希
+func (i FeedItem) Key() string {
+	if i.GUID != "" {
+		return "guid:" + i.GUID
+	}
+	if i.Link != "" {
+		return "link:" + i.Link
+	}
+	sum := sha1.Sum([]byte(i.Title + "\x00" + i.Summary + "\x00" + i.Description))
+	return "hash:" + hex.EncodeToString(sum[:])
 }
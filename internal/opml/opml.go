@@ -0,0 +1,156 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Document OPML 文档
+type Document struct {
+	XMLName xml.Name  `xml:"opml"`
+	Version string    `xml:"version,attr"`
+	Head    Head      `xml:"head"`
+	Body    []Outline `xml:"body>outline"`
+}
+
+// Head OPML 文档头
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+// Outline 订阅或分类。带 xmlUrl 的是订阅，其余的是包含子节点的分类
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Category string    `xml:"category,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Feed 展开后的订阅
+type Feed struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	Tags    []string // 所在的分类及 category 属性中的标签
+}
+
+// Parse 解析 OPML 文档
+func Parse(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("解析 OPML 失败: %v", err)
+	}
+	return &doc, nil
+}
+
+// Feeds 按文档顺序展开所有订阅，分类的名称作为标签
+func (d *Document) Feeds() []Feed {
+	var feeds []Feed
+	var walk func(outlines []Outline, tags []string)
+	walk = func(outlines []Outline, tags []string) {
+		for _, o := range outlines {
+			title := o.Title
+			if title == "" {
+				title = o.Text
+			}
+			if o.XMLURL == "" {
+				walk(o.Outlines, appendTags(tags, title))
+				continue
+			}
+			feeds = append(feeds, Feed{
+				Title:   title,
+				XMLURL:  strings.TrimSpace(o.XMLURL),
+				HTMLURL: o.HTMLURL,
+				Tags:    appendTags(tags, categories(o.Category)...),
+			})
+		}
+	}
+	walk(d.Body, nil)
+	return feeds
+}
+
+// New 创建 OPML 2.0 文档，订阅按第一个标签分组，没有标签的订阅放在顶层
+func New(title string, feeds []Feed, now time.Time) *Document {
+	doc := &Document{
+		Version: "2.0",
+		Head:    Head{Title: title, DateCreated: now.Format(time.RFC1123Z)},
+	}
+	groups := make(map[string]int)
+	for _, feed := range feeds {
+		o := Outline{
+			Text:     feed.Title,
+			Title:    feed.Title,
+			Type:     "rss",
+			XMLURL:   feed.XMLURL,
+			HTMLURL:  feed.HTMLURL,
+			Category: strings.Join(feed.Tags, ","),
+		}
+		if len(feed.Tags) == 0 {
+			doc.Body = append(doc.Body, o)
+			continue
+		}
+		i, ok := groups[feed.Tags[0]]
+		if !ok {
+			i = len(doc.Body)
+			groups[feed.Tags[0]] = i
+			doc.Body = append(doc.Body, Outline{Text: feed.Tags[0], Title: feed.Tags[0]})
+		}
+		doc.Body[i].Outlines = append(doc.Body[i].Outlines, o)
+	}
+	return doc
+}
+
+// Write 输出带 XML 声明的文档
+func (d *Document) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// categories 解析 category 属性：逗号分隔，每项可以是 "/" 分隔的路径
+func categories(attr string) []string {
+	var tags []string
+	for _, category := range strings.Split(attr, ",") {
+		for _, tag := range strings.Split(category, "/") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// appendTags 追加不重复的非空标签，返回新的切片
+func appendTags(tags []string, more ...string) []string {
+	result := append([]string(nil), tags...)
+	for _, tag := range more {
+		if tag == "" {
+			continue
+		}
+		duplicate := false
+		for _, t := range result {
+			if t == tag {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
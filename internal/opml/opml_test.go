@@ -0,0 +1,63 @@
+package opml
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="1.0">
+  <head><title>Reader export</title></head>
+  <body>
+    <outline text="Tech" title="Tech">
+      <outline text="Go Blog" type="rss" xmlUrl="https://go.dev/blog/feed.atom" htmlUrl="https://go.dev/blog"/>
+      <outline text="AI">
+        <outline text="Papers" type="rss" xmlUrl="https://example.com/papers.xml" category="research,/ml/nlp"/>
+      </outline>
+    </outline>
+    <outline text="Loose" type="rss" xmlUrl=" https://example.com/loose.xml "/>
+  </body>
+</opml>`
+
+func TestParseFeeds(t *testing.T) {
+	doc, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeds := doc.Feeds()
+	got := fmt.Sprint(feeds)
+	want := "[{Go Blog https://go.dev/blog/feed.atom https://go.dev/blog [Tech]} " +
+		"{Papers https://example.com/papers.xml  [Tech AI research ml nlp]} " +
+		"{Loose https://example.com/loose.xml  []}]"
+	if got != want {
+		t.Fatalf("订阅展开不符合预期:\n%s\n%s", got, want)
+	}
+}
+
+func TestNewRoundTrip(t *testing.T) {
+	feeds := []Feed{
+		{Title: "Go Blog", XMLURL: "https://go.dev/blog/feed.atom", Tags: []string{"Tech"}},
+		{Title: "Loose", XMLURL: "https://example.com/loose.xml"},
+		{Title: "Papers", XMLURL: "https://example.com/papers.xml", Tags: []string{"Tech", "AI"}},
+	}
+	var b bytes.Buffer
+	if err := New("rss-agent", feeds, time.Now()).Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `<opml version="2.0">`) {
+		t.Fatalf("应当输出 OPML 2.0: %s", b.String())
+	}
+	doc, err := Parse(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一分组的订阅放在一起，标签保留在 category 属性中
+	got := fmt.Sprint(doc.Feeds())
+	want := "[{Go Blog https://go.dev/blog/feed.atom  [Tech]} {Papers https://example.com/papers.xml  [Tech AI]} {Loose https://example.com/loose.xml  []}]"
+	if got != want {
+		t.Fatalf("导出后再导入不符合预期:\n%s\n%s", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package render
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/sanitize"
+)
+
+// Template 条目消息模板，每个条目渲染为一段轻量 markdown，链接使用 [文本](地址) 语法，
+// 由各渠道转换为自己的格式
+type Template struct {
+	tmpl *template.Template
+}
+
+// Item 模板数据，条目字段可直接引用，如 {{.Title}}；Feed 为所属的源，Index 为从 1 开始的序号
+type Item struct {
+	model.FeedItem
+	Feed  model.FeedData
+	Index int
+}
+
+var funcs = template.FuncMap{
+	"truncate":  truncate,
+	"date":      date,
+	"stripHTML": stripHTML,
+	"default":   defaultValue,
+}
+
+// Parse 解析模板
+func Parse(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// 用示例数据试渲染，提前发现引用了不存在的字段等错误
+	t := &Template{tmpl: tmpl}
+	now := time.Now()
+	sample := model.FeedItem{
+		Title:      "title",
+		Link:       "https://example.com",
+		Published:  now,
+		Updated:    now,
+		Authors:    []model.Person{{Name: "author"}},
+		Categories: []string{"category"},
+		Enclosures: []model.Enclosure{{URL: "https://example.com/enclosure"}},
+	}
+	if _, err := t.Execute(model.FeedData{Title: "feed", Items: []model.FeedItem{sample}}, 0, sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Execute 渲染源中的第 index 个条目
+func (t *Template) Execute(feed model.FeedData, index int, item model.FeedItem) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, Item{FeedItem: item, Feed: feed, Index: index + 1}); err != nil {
+		return "", fmt.Errorf("渲染模板 %s 失败: %v", t.tmpl.Name(), err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// truncate 按字符数截断，用法：{{.Summary | truncate 100}}
+func truncate(length int, s string) string {
+	return sanitize.Truncate(s, length)
+}
+
+// date 按时区格式化时间，时区为空时使用本地时区，用法：{{date "2006-01-02 15:04" "Asia/Shanghai" .Published}}
+func date(layout, timezone string, t time.Time) (string, error) {
+	if t.IsZero() {
+		return "", nil
+	}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return "", err
+		}
+		t = t.In(loc)
+	}
+	return t.Format(layout), nil
+}
+
+// stripHTML 将 HTML 转换为纯文本，用法：{{.Description | stripHTML}}
+func stripHTML(s string) string {
+	return sanitize.HTML(s, false)
+}
+
+// defaultValue 值为空时使用默认值，用法：{{.Author | default "佚名"}}
+func defaultValue(def interface{}, value interface{}) interface{} {
+	if value == nil {
+		return def
+	}
+	v := reflect.ValueOf(value)
+	if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") {
+		return def
+	}
+	return value
+}
+
+// Segment 渲染结果中的一段文本，URL 不为空时为链接
+type Segment struct {
+	Text string
+	URL  string
+}
+
+var linkRe = regexp.MustCompile(`\[([^\[\]]*)\]\((https?://[^\s()]+)\)`)
+
+// Segments 按 markdown 链接拆分文本，供不支持 markdown 的渠道转换链接
+func Segments(text string) []Segment {
+	var segments []Segment
+	last := 0
+	for _, m := range linkRe.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > last {
+			segments = append(segments, Segment{Text: text[last:m[0]]})
+		}
+		segments = append(segments, Segment{Text: text[m[2]:m[3]], URL: text[m[4]:m[5]]})
+		last = m[1]
+	}
+	if last < len(text) {
+		segments = append(segments, Segment{Text: text[last:]})
+	}
+	return segments
+}
@@ -0,0 +1,54 @@
+package render
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestExecute(t *testing.T) {
+	tmpl, err := Parse("item", `{{.Index}}. [{{.Title}}]({{.Link}}) {{.Feed.Title}}
+{{.Summary | stripHTML | truncate 12}}
+{{.Author | default "佚名"}} {{date "2006-01-02 15:04" "Asia/Shanghai" .Published}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed := model.FeedData{Title: "Blogs"}
+	item := model.FeedItem{
+		Title:     "Hello",
+		Link:      "https://example.com",
+		Summary:   "<p>hello <b>world</b> and more</p>",
+		Published: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	got, err := tmpl.Execute(feed, 1, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2. [Hello](https://example.com) Blogs\nhello world…\n佚名 2024-01-01 08:00"
+	if got != want {
+		t.Fatalf("期望 %q，实际 %q", want, got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, text := range []string{"{{.Title", "{{.Missing}}", "{{.Title | unknown}}", `{{date "2006" "Mars/Base" .Published}}`} {
+		if _, err := Parse("item", text); err == nil {
+			t.Fatalf("模板 %q 应解析失败", text)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	got := Segments("see [a](https://a.com) and [b](/relative) [c](http://c.com)")
+	want := []Segment{
+		{Text: "see "},
+		{Text: "a", URL: "https://a.com"},
+		{Text: " and [b](/relative) "},
+		{Text: "c", URL: "http://c.com"},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("期望 %v，实际 %v", want, got)
+	}
+}
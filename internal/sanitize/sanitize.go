@@ -0,0 +1,226 @@
+package sanitize
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+	"golang.org/x/net/html"
+)
+
+// skipped 不输出内容的元素
+var skipped = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true,
+	"head": true, "template": true, "svg": true, "img": true,
+}
+
+// blocks 前后换行的块级元素
+var blocks = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "header": true, "footer": true,
+	"blockquote": true, "pre": true, "table": true, "tr": true, "ul": true, "ol": true,
+	"figure": true, "figcaption": true, "dl": true, "dt": true, "dd": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// Feed 清洗源中所有条目的摘要和描述，format 为 text 或 markdown，为空时不做处理；
+// maxLength 为最大字符数，0 表示不限制
+func Feed(data *model.FeedData, format string, maxLength int) {
+	if format == "" {
+		return
+	}
+	markdown := format == constants.SanitizeMarkdown
+	data.Description = Truncate(HTML(data.Description, false), maxLength)
+	for i, item := range data.Items {
+		data.Items[i].Summary = Truncate(HTML(item.Summary, markdown), maxLength)
+		data.Items[i].Description = Truncate(HTML(item.Description, markdown), maxLength)
+	}
+}
+
+// HTML 将 HTML 转换为可读文本：丢弃脚本、样式和图片，解码实体，合并空白。
+// markdown 为 true 时输出轻量 markdown，保留链接、加粗、标题和列表
+func HTML(s string, markdown bool) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(s))
+	if err != nil {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	w := &textWriter{markdown: markdown}
+	for _, n := range doc.Nodes {
+		w.node(n)
+	}
+	return w.b.String()
+}
+
+// textWriter 遍历 HTML 节点输出文本，连续的空白合并为一个空格，块级元素之间换行
+type textWriter struct {
+	b        strings.Builder
+	markdown bool
+	space    bool // 待输出的空格
+	breaks   int  // 待输出的换行数
+}
+
+func (w *textWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+
+	tag := n.Data
+	switch {
+	case skipped[tag]:
+		return
+	case tag == "br":
+		w.lineBreak(1)
+		return
+	case tag == "a":
+		w.link(n)
+		return
+	case tag == "li":
+		w.lineBreak(1)
+		w.write("-")
+		w.space = true
+		w.children(n)
+		w.lineBreak(1)
+		return
+	case w.markdown && (tag == "strong" || tag == "b"):
+		w.wrap(n, "**")
+		return
+	case w.markdown && len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+		w.lineBreak(2)
+		w.wrap(n, "**")
+		w.lineBreak(2)
+		return
+	}
+
+	if blocks[tag] {
+		w.lineBreak(2)
+		w.children(n)
+		w.lineBreak(2)
+		return
+	}
+	w.children(n)
+}
+
+func (w *textWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+// inner 渲染子节点为单行文本
+func (w *textWriter) inner(n *html.Node) string {
+	sub := &textWriter{markdown: w.markdown}
+	sub.children(n)
+	return strings.Join(strings.Fields(sub.b.String()), " ")
+}
+
+// wrap 用 marker 包裹子节点的文本，子节点为空时不输出
+func (w *textWriter) wrap(n *html.Node, marker string) {
+	if text := w.inner(n); text != "" {
+		w.write(marker + text + marker)
+	}
+}
+
+// link 输出链接：markdown 为 [文本](地址)，纯文本为 文本 (地址)
+func (w *textWriter) link(n *html.Node) {
+	text := w.inner(n)
+	href := ""
+	for _, attr := range n.Attr {
+		if attr.Key == "href" {
+			href = strings.TrimSpace(attr.Val)
+		}
+	}
+	if !strings.HasPrefix(href, "http://") && !strings.HasPrefix(href, "https://") {
+		w.write(text)
+		return
+	}
+	switch {
+	case text == "" || text == href:
+		w.write(href)
+	case w.markdown:
+		w.write("[" + text + "](" + href + ")")
+	default:
+		w.write(text + " (" + href + ")")
+	}
+}
+
+// text 输出文本节点，合并空白
+func (w *textWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	if unicode.IsSpace(rune(s[0])) {
+		w.space = true
+	}
+	for i, field := range strings.Fields(s) {
+		if i > 0 {
+			w.space = true
+		}
+		w.write(field)
+	}
+	if unicode.IsSpace(rune(s[len(s)-1])) {
+		w.space = true
+	}
+}
+
+// write 先输出待输出的换行或空格，再原样输出 s
+func (w *textWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if w.b.Len() > 0 {
+		if w.breaks > 0 {
+			w.b.WriteString(strings.Repeat("\n", w.breaks))
+		} else if w.space {
+			w.b.WriteByte(' ')
+		}
+	}
+	w.breaks, w.space = 0, false
+	w.b.WriteString(s)
+}
+
+func (w *textWriter) lineBreak(n int) {
+	if n > w.breaks {
+		w.breaks = n
+	}
+}
+
+// Truncate 按字符数截断文本并加上省略号，尽量在词的边界处截断。
+// 中日韩文字没有空格分词，可在任意字符处截断。maxLength 为 0 时不截断
+func Truncate(s string, maxLength int) string {
+	runes := []rune(s)
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return s
+	}
+	cut := maxLength - 1
+	if cut <= 0 {
+		return "…"
+	}
+	// 截断点位于单词中间时，回退到前一个空白，但最多回退一半
+	if midWord(runes[cut-1]) && midWord(runes[cut]) {
+		for i := cut - 1; i > cut/2; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
+
+// midWord 字符是否可能位于需要保持完整的单词中
+func midWord(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.Is(unicode.Han, r) &&
+		!unicode.Is(unicode.Hiragana, r) && !unicode.Is(unicode.Katakana, r) && !unicode.Is(unicode.Hangul, r)
+}
@@ -0,0 +1,79 @@
+package sanitize
+
+import (
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		markdown bool
+		want     string
+	}{
+		{"纯文本", "  hello \n world ", false, "hello world"},
+		{"实体", "<p>AT&amp;T &lt;3 &quot;x&quot;</p>", false, `AT&T <3 "x"`},
+		{"脚本和图片", `<p>a<script>alert(1)</script><style>p{}</style><img src="x.png">b</p>`, false, "ab"},
+		{"段落", "<p>first</p><p>second<br>line</p>", false, "first\n\nsecond\nline"},
+		{"纯文本链接", `read <a href="https://example.com">more</a>.`, false, "read more (https://example.com)."},
+		{"markdown 链接", `read <a href="https://example.com"> more </a>`, true, "read [more](https://example.com)"},
+		{"相对链接", `<a href="/x">more</a>`, true, "more"},
+		{"markdown 标题和加粗", "<h3>Title</h3><p>a <b>bold</b> word</p>", true, "**Title**\n\na **bold** word"},
+		{"列表", "<ul><li> one</li><li>two</li></ul>", false, "- one\n- two"},
+		{"中文", "<p>你好，<strong>世界</strong></p>", false, "你好，世界"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.in, tt.markdown); got != tt.want {
+				t.Fatalf("期望 %q，实际 %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"hello world foo", 0, "hello world foo"},
+		{"hello world, foobar", 16, "hello world…"},
+		{"supercalifragilistic", 10, "supercali…"},
+		{"你好世界你好世界", 5, "你好世界…"},
+	}
+	for _, tt := range tests {
+		if got := Truncate(tt.in, tt.max); got != tt.want {
+			t.Fatalf("Truncate(%q, %d) 期望 %q，实际 %q", tt.in, tt.max, tt.want, got)
+		}
+	}
+}
+
+func TestFeed(t *testing.T) {
+	data := &model.FeedData{
+		Description: "<p>desc</p>",
+		Items: []model.FeedItem{{
+			Summary:     "<p>summary with <a href=\"https://example.com\">link</a></p>",
+			Description: "<div>long long long content</div>",
+		}},
+	}
+	Feed(data, "", 0)
+	if data.Description != "<p>desc</p>" {
+		t.Fatalf("未配置格式时不应清洗: %q", data.Description)
+	}
+
+	Feed(data, constants.SanitizeMarkdown, 20)
+	if data.Description != "desc" {
+		t.Fatalf("源描述不符合预期: %q", data.Description)
+	}
+	if got := data.Items[0].Summary; got != "summary with…" {
+		t.Fatalf("摘要不符合预期: %q", got)
+	}
+	if got := data.Items[0].Description; got != "long long long…" {
+		t.Fatalf("描述不符合预期: %q", got)
+	}
+}
@@ -2,100 +2,81 @@ package service
 
 import (
 	"fmt"
-	"os"
+	"time"
 
-	jsoniter "github.com/json-iterator/go"
 	"github.com/robfig/cron/v3"
 	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
 	"github.com/weirwei/rss-agent/internal/log"
 	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
 )
 
 // AgentHelper 消息发送助手服务
 type AgentHelper struct {
-	agents   map[string]AgentConfig
-	inputDir string
-	cron     *cron.Cron
+	agents []AgentConfig
+	store  store.Store
+	cron   *cron.Cron
 }
 
-// AgentConfig 代理配置
+// AgentConfig 代理配置：按 Cron 将源 Feed 最新快照中未发送过的条目发送到渠道 Channel
 type AgentConfig struct {
-	Agent agent.Agent
-	Cron  string
+	Feed      constants.AgentName
+	Channel   string
+	Agent     agent.Agent
+	Cron      string
+	Length    int    // 每次最多发送的条目数，与渠道渲染的条目数一致，0 表示不限制
+	NoUpdates string // 没有未发送过的条目时的处理方式，见 constants.NoUpdatesSkip
 }
 
-// NewAgentHelper 创建新的发送助手实例
-func NewAgentHelper(inputDir string) *AgentHelper {
-	if inputDir == "" {
-		inputDir = "rss_output"
-	}
+// deliveredPrefix 每个渠道已发送条目的记录名称前缀，与源的已见条目分开存储
+const deliveredPrefix = "@"
+
+// deliveredName 返回渠道已发送条目的记录名称
+func deliveredName(channel string) constants.AgentName {
+	return constants.AgentName(deliveredPrefix + channel)
+}
 
+// NewAgentHelper 创建新的发送助手实例
+func NewAgentHelper(st store.Store) *AgentHelper {
 	return &AgentHelper{
-		agents:   make(map[string]AgentConfig),
-		inputDir: inputDir,
-		cron:     cron.New(),
+		store: st,
+		cron:  cron.New(),
 	}
 }
 
-// AddAgent 添加发送代理
-func (a *AgentHelper) AddAgent(name string, agent agent.Agent, cronExpr string) {
-	a.agents[name] = AgentConfig{
-		Agent: agent,
-		Cron:  cronExpr,
-	}
+// AddAgent 添加发送代理，按渠道的 cron、length 和 no_updates 配置发送
+func (a *AgentHelper) AddAgent(feed constants.AgentName, agent agent.Agent, channel config.ChannelConfig) {
+	a.agents = append(a.agents, AgentConfig{
+		Feed:      feed,
+		Channel:   channel.Name,
+		Agent:     agent,
+		Cron:      channel.Cron,
+		Length:    channel.Length,
+		NoUpdates: channel.NoUpdates,
+	})
 }
 
 // SendAll 发送所有消息
 func (a *AgentHelper) SendAll() {
-	for name, agentConfig := range a.agents {
-		// 读取对应的数据文件
-		file, err := os.ReadFile(fmt.Sprintf("%s/%s.json", a.inputDir, name))
-		if err != nil {
-			log.Error("读取文件失败 %s: %v", name, err)
-			continue
-		}
-		var feedData model.FeedData
-		err = jsoniter.Unmarshal(file, &feedData)
-		if err != nil {
-			log.Error("解析 JSON 数据失败 %s: %v", name, err)
-			continue
-		}
-		// 发送消息
-		err = agentConfig.Agent.Send(feedData)
-		if err != nil {
-			log.Error("发送消息失败 %s: %v", name, err)
-		}
+	for _, agentConfig := range a.agents {
+		a.send(agentConfig)
 	}
 }
 
 // StartSchedule 启动定时任务
 func (a *AgentHelper) StartSchedule() error {
-	for name, agentConfig := range a.agents {
-		agentName := name // 创建副本用于闭包
-		agent := agentConfig.Agent
-		log.Info("启动定时发送任务: %s", agentName)
+	for _, agentConfig := range a.agents {
+		agentConfig := agentConfig // 创建副本用于闭包
+		log.Info("启动定时发送任务: %s -> %s", agentConfig.Feed, agentConfig.Channel)
 		_, err := a.cron.AddFunc(agentConfig.Cron, func() {
-			log.Info("执行定时发送任务: %s", agentName)
-			file, err := os.ReadFile(fmt.Sprintf("%s/%s.json", a.inputDir, agentName))
-			if err != nil {
-				log.Error("读取文件失败 %s: %v", agentName, err)
-				return
-			}
-
-			var feedData model.FeedData
-			err = jsoniter.Unmarshal(file, &feedData)
-			if err != nil {
-				log.Error("解析 JSON 数据失败 %s: %v", agentName, err)
-				return
-			}
-			err = agent.Send(feedData)
-			if err != nil {
-				log.Error("发送消息失败 %s: %v", agentName, err)
-			}
+			log.Info("执行定时发送任务: %s -> %s", agentConfig.Feed, agentConfig.Channel)
+			a.send(agentConfig)
 		})
 
 		if err != nil {
-			return fmt.Errorf("添加定时任务失败 %s: %v", name, err)
+			return fmt.Errorf("添加定时任务失败 %s -> %s: %v", agentConfig.Feed, agentConfig.Channel, err)
 		}
 	}
 
@@ -104,6 +85,72 @@ func (a *AgentHelper) StartSchedule() error {
 	return nil
 }
 
+// send 读取源的最新快照，发送其中未发送到该渠道的条目并记录发送结果
+func (a *AgentHelper) send(agentConfig AgentConfig) {
+	name := agentConfig.Feed
+	feedData, err := a.store.LoadFeed(name)
+	if err != nil {
+		log.Error("读取源数据失败 %s: %v", name, err)
+		return
+	}
+	if feedData == nil {
+		log.Error("源数据不存在 %s", name)
+		return
+	}
+	delivered := deliveredName(agentConfig.Channel)
+	items, err := a.store.FilterNew(delivered, feedData.Items)
+	if err != nil {
+		log.Error("筛选未发送条目失败 %s -> %s: %v", name, agentConfig.Channel, err)
+		return
+	}
+	// 渠道只渲染前 length 个条目，其余条目不记为已发送，留到下次发送
+	if agentConfig.Length > 0 && len(items) > agentConfig.Length {
+		log.Info("源 %s 有 %d 条未发送到渠道 %s 的条目，本次发送 %d 条", name, len(items), agentConfig.Channel, agentConfig.Length)
+		items = items[:agentConfig.Length]
+	}
+	data := *feedData
+	// 格式化器会修改条目，复制一份以免影响已发送记录的键
+	data.Items = append([]model.FeedItem(nil), items...)
+	if len(items) == 0 {
+		if agentConfig.NoUpdates != constants.NoUpdatesNotify {
+			log.Info("源 %s 没有未发送到渠道 %s 的条目，跳过", name, agentConfig.Channel)
+			return
+		}
+		data.Items = []model.FeedItem{noUpdatesItem(time.Now())}
+	}
+
+	delivery := store.Delivery{
+		Feed:    name,
+		Channel: agentConfig.Channel,
+		SentAt:  time.Now(),
+	}
+	for _, item := range items {
+		delivery.ItemKeys = append(delivery.ItemKeys, item.Key())
+	}
+	err = agentConfig.Agent.Send(data)
+	if err != nil {
+		log.Error("发送消息失败 %s -> %s: %v", name, agentConfig.Channel, err)
+		delivery.Error = err.Error()
+	}
+	if err := a.store.RecordDelivery(delivery); err != nil {
+		log.Error("记录发送结果失败 %s: %v", name, err)
+	}
+	if err == nil {
+		if err := a.store.MarkSeen(delivered, items); err != nil {
+			log.Error("记录已发送条目失败 %s -> %s: %v", name, agentConfig.Channel, err)
+		}
+	}
+}
+
+// noUpdatesItem 没有新条目时发送的提示
+func noUpdatesItem(now time.Time) model.FeedItem {
+	return model.FeedItem{
+		Title:       "暂无更新",
+		Description: "自上次发送以来没有新内容",
+		Published:   now,
+	}
+}
+
 // Stop 停止定时任务
 func (a *AgentHelper) Stop() {
 	if a.cron != nil {
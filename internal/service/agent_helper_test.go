@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+func TestAgentHelperSkipDelivered(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	feed := model.FeedData{Title: "daily", Items: []model.FeedItem{{GUID: "1"}, {GUID: "2"}}}
+	if err := st.SaveFeed("daily", feed); err != nil {
+		t.Fatal(err)
+	}
+	skip, notify := &recordingAgent{}, &recordingAgent{}
+	a := NewAgentHelper(st)
+	a.AddAgent("daily", skip, channelConfig("skip", 0, constants.NoUpdatesSkip))
+	a.AddAgent("daily", notify, channelConfig("notify", 0, constants.NoUpdatesNotify))
+
+	// 快照未变化时再次发送：skip 渠道不发送，notify 渠道发送提示
+	a.SendAll()
+	a.SendAll()
+	if got := fmt.Sprint(skip.sent); got != "[[1 2]]" {
+		t.Fatalf("skip 渠道发送不符合预期: %s", got)
+	}
+	if got := fmt.Sprint(notify.sent); got != "[[1 2] []]" {
+		t.Fatalf("notify 渠道发送不符合预期: %s", got)
+	}
+
+	// 快照更新后只发送未发送过的条目
+	feed.Items = append(feed.Items, model.FeedItem{GUID: "3"})
+	if err := st.SaveFeed("daily", feed); err != nil {
+		t.Fatal(err)
+	}
+	a.SendAll()
+	if got := fmt.Sprint(skip.sent); got != "[[1 2] [3]]" {
+		t.Fatalf("快照更新后发送不符合预期: %s", got)
+	}
+}
+
+func TestAgentHelperLength(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	feed := model.FeedData{Title: "daily", Items: []model.FeedItem{{GUID: "1"}, {GUID: "2"}, {GUID: "3"}}}
+	if err := st.SaveFeed("daily", feed); err != nil {
+		t.Fatal(err)
+	}
+	ag := &recordingAgent{}
+	a := NewAgentHelper(st)
+	a.AddAgent("daily", ag, channelConfig("daily", 2, constants.NoUpdatesSkip))
+
+	// 超出 length 的条目不记为已发送，下次发送
+	a.SendAll()
+	a.SendAll()
+	if got := fmt.Sprint(ag.sent); got != "[[1 2] [3]]" {
+		t.Fatalf("按 length 发送不符合预期: %s", got)
+	}
+}
+
+func channelConfig(name string, length int, noUpdates string) config.ChannelConfig {
+	return config.ChannelConfig{
+		Name:        name,
+		AgentConfig: config.AgentConfig{Cron: "0 16 * * *", Length: length, NoUpdates: noUpdates},
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+// DigestHelper 汇总发送服务：源的新增条目先写入渠道的汇总队列，
+// 按渠道的 cron 将上次汇总以来的条目按源分组，经发件箱发送后从队列中删除
+type DigestHelper struct {
+	store    store.Store
+	outbox   *Outbox
+	channels map[string]digestChannel
+	cron     *cron.Cron
+
+	mu         sync.Mutex
+	formatters map[constants.AgentName]agent.DataFormatter
+}
+
+// digestChannel 汇总发送的渠道
+type digestChannel struct {
+	cron     string
+	maxItems int
+}
+
+// NewDigestHelper 创建汇总发送服务
+func NewDigestHelper(st store.Store, outbox *Outbox) *DigestHelper {
+	return &DigestHelper{
+		store:      st,
+		outbox:     outbox,
+		channels:   make(map[string]digestChannel),
+		cron:       cron.New(),
+		formatters: make(map[constants.AgentName]agent.DataFormatter),
+	}
+}
+
+// AddChannel 添加汇总发送的渠道
+func (d *DigestHelper) AddChannel(channel string, cfg config.DigestConfig, cronExpr string) {
+	d.channels[channel] = digestChannel{cron: cronExpr, maxItems: cfg.MaxItems}
+}
+
+// HasChannel 判断渠道是否为汇总发送
+func (d *DigestHelper) HasChannel(channel string) bool {
+	_, ok := d.channels[channel]
+	return ok
+}
+
+// Agent 返回将源的增量写入渠道汇总队列的代理，供抓取器在 Complete 中使用
+func (d *DigestHelper) Agent(feed constants.AgentName, channel string) agent.Agent {
+	return &digestAgent{helper: d, feed: feed, channel: channel}
+}
+
+// StartSchedule 启动定时汇总任务
+func (d *DigestHelper) StartSchedule() error {
+	for channel, ch := range d.channels {
+		channel := channel // 创建副本用于闭包
+		log.Info("启动定时汇总任务: %s", channel)
+		_, err := d.cron.AddFunc(ch.cron, func() {
+			log.Info("执行定时汇总任务: %s", channel)
+			if err := d.Send(channel); err != nil {
+				log.Error("发送汇总失败 %s: %v", channel, err)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("添加汇总任务失败 %s: %v", channel, err)
+		}
+	}
+	d.cron.Start()
+	return nil
+}
+
+// Stop 停止定时汇总任务
+func (d *DigestHelper) Stop() {
+	d.cron.Stop()
+}
+
+// Send 将渠道汇总队列中的条目按源分组发送，每个源一条消息，最多显示 maxItems 条。
+// 消息写入发件箱后，该源的条目（包括未显示的）从队列中删除
+func (d *DigestHelper) Send(channel string) error {
+	items, err := d.store.DigestItems(channel)
+	if err != nil {
+		return fmt.Errorf("读取汇总队列失败: %v", err)
+	}
+	if len(items) == 0 {
+		log.Info("渠道 %s 没有待汇总的条目", channel)
+		return nil
+	}
+
+	var order []constants.AgentName
+	groups := make(map[constants.AgentName][]store.DigestItem)
+	for _, item := range items {
+		if _, ok := groups[item.Feed]; !ok {
+			order = append(order, item.Feed)
+		}
+		groups[item.Feed] = append(groups[item.Feed], item)
+	}
+
+	maxItems := d.channels[channel].maxItems
+	for _, feed := range order {
+		group := groups[feed]
+		data := digestData(feed, group, maxItems)
+		ag := d.outbox.Agent(feed, channel)
+		ag.SetFormatter(d.formatter(feed))
+		if err := ag.Send(data); err != nil {
+			return fmt.Errorf("%s: %v", feed, err)
+		}
+		ids := make([]uint64, 0, len(group))
+		for _, item := range group {
+			ids = append(ids, item.ID)
+		}
+		if err := d.store.RemoveDigestItems(channel, ids); err != nil {
+			return fmt.Errorf("删除已汇总条目失败 %s: %v", feed, err)
+		}
+		log.Info("源 %s 汇总 %d 条到渠道 %s", feed, len(group), channel)
+	}
+	return nil
+}
+
+// digestData 将一个源的待汇总条目按发布时间从新到旧排列，保留前 maxItems 条
+func digestData(feed constants.AgentName, group []store.DigestItem, maxItems int) model.FeedData {
+	items := make([]model.FeedItem, 0, len(group))
+	for _, item := range group {
+		items = append(items, item.Item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.After(items[j].Published)
+	})
+
+	title := group[0].FeedTitle
+	if title == "" {
+		title = string(feed)
+	}
+	if maxItems > 0 && len(items) > maxItems {
+		title = fmt.Sprintf("%s 汇总（共 %d 条，显示 %d 条）", title, len(items), maxItems)
+		items = items[:maxItems]
+	} else {
+		title = fmt.Sprintf("%s 汇总（%d 条）", title, len(items))
+	}
+	return model.FeedData{
+		Feed:  string(feed),
+		Title: title,
+		Items: items,
+	}
+}
+
+func (d *DigestHelper) formatter(feed constants.AgentName) agent.DataFormatter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.formatters[feed]
+}
+
+// digestAgent 将源的增量写入渠道的汇总队列
+type digestAgent struct {
+	helper  *DigestHelper
+	feed    constants.AgentName
+	channel string
+}
+
+func (a *digestAgent) Send(data model.FeedData) error {
+	now := time.Now()
+	items := make([]store.DigestItem, 0, len(data.Items))
+	for _, item := range data.Items {
+		items = append(items, store.DigestItem{
+			Feed:      a.feed,
+			FeedTitle: data.Title,
+			Item:      item,
+			AddedAt:   now,
+		})
+	}
+	if err := a.helper.store.AddDigestItems(a.channel, items); err != nil {
+		return fmt.Errorf("写入汇总队列失败: %v", err)
+	}
+	log.Info("源 %s 的 %d 条新增条目加入渠道 %s 的汇总队列", a.feed, len(items), a.channel)
+	return nil
+}
+
+// SetFormatter 格式化器在发送汇总时应用，避免修改队列中条目的键
+func (a *digestAgent) SetFormatter(formatter agent.DataFormatter) {
+	a.helper.mu.Lock()
+	defer a.helper.mu.Unlock()
+	a.helper.formatters[a.feed] = formatter
+}
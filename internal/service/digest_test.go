@@ -0,0 +1,52 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+func TestDigestHelper(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	o := NewOutbox(st, config.OutboxConfig{})
+	ag := &recordingAgent{}
+	o.Register("weekly", ag)
+	d := NewDigestHelper(st, o)
+	d.AddChannel("weekly", config.DigestConfig{Enabled: true, MaxItems: 2}, "0 9 * * 1")
+
+	now := time.Now()
+	for i, batch := range [][]string{{"1", "2"}, {"3"}} {
+		data := model.FeedData{Title: "Blogs"}
+		for _, guid := range batch {
+			data.Items = append(data.Items, model.FeedItem{GUID: guid, Published: now.Add(time.Duration(len(data.Items)+i*2) * time.Hour)})
+		}
+		if err := d.Agent("blogs", "weekly").Send(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Agent("news", "weekly").Send(model.FeedData{Title: "News", Items: []model.FeedItem{{GUID: "n1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ag.sent) != 0 {
+		t.Fatalf("汇总前不应发送: %v", ag.sent)
+	}
+
+	if err := d.Send("weekly"); err != nil {
+		t.Fatal(err)
+	}
+	// 每个源一条消息，按发布时间从新到旧，超出上限的条目不显示
+	if got := fmt.Sprint(ag.sent); got != "[[3 2] [n1]]" {
+		t.Fatalf("汇总消息不符合预期: %s", got)
+	}
+	// 已发送的条目从汇总队列中删除，下一次汇总没有内容
+	if err := d.Send("weekly"); err != nil {
+		t.Fatal(err)
+	}
+	if len(ag.sent) != 2 {
+		t.Fatalf("已汇总的条目不应重复发送: %v", ag.sent)
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/httpclient"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+const (
+	defaultBackoffBase = time.Minute
+	defaultBackoffMax  = 6 * time.Hour
+	defaultAlertAfter  = 3
+	defaultPauseAfter  = 10
+)
+
+// backoffPolicy 指数退避策略
+type backoffPolicy struct {
+	base time.Duration
+	max  time.Duration
+}
+
+// next 计算连续失败 failures 次后的等待时间
+func (p backoffPolicy) next(failures int) time.Duration {
+	wait := p.base
+	for i := 1; i < failures && wait < p.max; i++ {
+		wait *= 2
+	}
+	if wait > p.max {
+		wait = p.max
+	}
+	return wait
+}
+
+// healthPolicy 抓取失败的退避、暂停和告警策略
+type healthPolicy struct {
+	backoff    backoffPolicy
+	alertAfter int
+	pauseAfter int
+}
+
+func newHealthPolicy(cfg config.HealthConfig) healthPolicy {
+	p := healthPolicy{
+		backoff:    backoffPolicy{base: defaultBackoffBase, max: defaultBackoffMax},
+		alertAfter: defaultAlertAfter,
+		pauseAfter: defaultPauseAfter,
+	}
+	if cfg.BackoffBase > 0 {
+		p.backoff.base = time.Duration(cfg.BackoffBase) * time.Minute
+	}
+	if cfg.BackoffMax > 0 {
+		p.backoff.max = time.Duration(cfg.BackoffMax) * time.Minute
+	}
+	if cfg.AlertAfter > 0 {
+		p.alertAfter = cfg.AlertAfter
+	}
+	if cfg.PauseAfter > 0 {
+		p.pauseAfter = cfg.PauseAfter
+	}
+	return p
+}
+
+// SetAlertAgent 设置源故障告警和恢复通知的发送代理
+func (r *RSSHelper) SetAlertAgent(ag agent.Agent) {
+	r.alert = ag
+}
+
+// shouldSkip 判断源是否因暂停或退避而跳过本次抓取
+func (r *RSSHelper) shouldSkip(name constants.AgentName) bool {
+	h, err := r.store.Health(name)
+	if err != nil {
+		log.Error("读取健康状况失败 %s: %v", name, err)
+		return false
+	}
+	if h.Paused {
+		log.Info("源 %s 已暂停，连续失败 %d 次，最后错误：%s", name, h.ConsecutiveFailures, h.LastError)
+		return true
+	}
+	if time.Now().Before(h.NextRetry) {
+		log.Info("源 %s 退避中，下次重试时间：%s", name, h.NextRetry.Format(time.DateTime))
+		return true
+	}
+	return false
+}
+
+// updateHealth 根据抓取结果更新源的健康状况，必要时发送告警或恢复通知
+func (r *RSSHelper) updateHealth(name constants.AgentName, fetchErr error) {
+	h, err := r.store.Health(name)
+	if err != nil {
+		log.Error("读取健康状况失败 %s: %v", name, err)
+		return
+	}
+	now := time.Now()
+	if fetchErr == nil || errors.Is(fetchErr, httpclient.ErrNotModified) {
+		if h.Alerted {
+			detail := fmt.Sprintf("此前连续失败 %d 次，最后错误：%s", h.ConsecutiveFailures, h.LastError)
+			if h.ConsecutiveFailures == 0 {
+				detail = "已手动恢复，最后错误：" + h.LastError
+			}
+			r.sendAlert(name, fmt.Sprintf("源 %s 已恢复", name), detail)
+		}
+		h = store.FeedHealth{LastSuccess: now}
+	} else {
+		h.ConsecutiveFailures++
+		h.LastError = fetchErr.Error()
+		h.LastFailure = now
+		h.NextRetry = now.Add(r.health.backoff.next(h.ConsecutiveFailures))
+		if h.ConsecutiveFailures >= r.health.pauseAfter {
+			h.Paused = true
+			log.Error("源 %s 连续失败 %d 次，已自动暂停", name, h.ConsecutiveFailures)
+		}
+		if !h.Alerted && (h.ConsecutiveFailures >= r.health.alertAfter || h.Paused) {
+			h.Alerted = r.sendAlert(name, fmt.Sprintf("源 %s 抓取失败", name),
+				fmt.Sprintf("已连续失败 %d 次，最后错误：%s", h.ConsecutiveFailures, h.LastError))
+		}
+	}
+	if err := r.store.SaveHealth(name, h); err != nil {
+		log.Error("保存健康状况失败 %s: %v", name, err)
+	}
+}
+
+// sendAlert 通过告警代理发送通知，返回是否发送成功
+func (r *RSSHelper) sendAlert(name constants.AgentName, title, detail string) bool {
+	if r.alert == nil {
+		return false
+	}
+	err := r.alert.Send(model.FeedData{
+		Title:       title,
+		LastUpdated: time.Now(),
+		Items: []model.FeedItem{{
+			Title:       string(name),
+			Link:        r.feeds[name].URL,
+			Published:   time.Now(),
+			Description: detail,
+		}},
+	})
+	if err != nil {
+		log.Error("发送告警失败 %s: %v", name, err)
+		return false
+	}
+	return true
+}
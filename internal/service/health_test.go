@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+type failingFetcher struct {
+	err error
+}
+
+func (f *failingFetcher) Fetch(ctx context.Context, url string) (*model.FeedData, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &model.FeedData{}, nil
+}
+
+func (f *failingFetcher) Complete(data *model.FeedData) error {
+	return nil
+}
+
+type recordAgent struct {
+	sent []model.FeedData
+}
+
+func (a *recordAgent) Send(data model.FeedData) error {
+	a.sent = append(a.sent, data)
+	return nil
+}
+
+func (a *recordAgent) SetFormatter(formatter agent.DataFormatter) {}
+
+func TestFeedHealth(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	r := NewRSSHelper(st, nil, config.FetcherConfig{
+		Health: config.HealthConfig{AlertAfter: 2, PauseAfter: 3},
+	})
+	alert := &recordAgent{}
+	r.SetAlertAgent(alert)
+	f := &failingFetcher{err: errors.New("boom")}
+	r.AddFeed("test", f, config.FeedConfig{URL: "https://example.com/rss"})
+
+	for i := 1; i <= 3; i++ {
+		if result := r.fetchOne("test"); result != fetchResultFailed {
+			t.Fatalf("第 %d 次抓取期望失败，实际 %d", i, result)
+		}
+		// 退避中会跳过抓取
+		if result := r.fetchOne("test"); result != fetchResultSkipped {
+			t.Fatalf("第 %d 次失败后期望退避，实际 %d", i, result)
+		}
+		h, _ := st.Health("test")
+		h.NextRetry = time.Time{}
+		st.SaveHealth("test", h)
+	}
+	h, _ := st.Health("test")
+	if !h.Paused || h.ConsecutiveFailures != 3 || len(alert.sent) != 1 {
+		t.Fatalf("期望暂停并只告警一次: %+v, %d", h, len(alert.sent))
+	}
+	if result := r.fetchOne("test"); result != fetchResultSkipped {
+		t.Fatalf("暂停后期望跳过，实际 %d", result)
+	}
+
+	// 恢复后发送恢复通知
+	st.SaveHealth("test", store.FeedHealth{ConsecutiveFailures: 3, Alerted: true})
+	f.err = nil
+	r.fetchOne("test")
+	h, _ = st.Health("test")
+	if h.ConsecutiveFailures != 0 || h.LastSuccess.IsZero() || len(alert.sent) != 2 {
+		t.Fatalf("恢复不符合预期: %+v, %d", h, len(alert.sent))
+	}
+}
+
+func TestFeedHealthResume(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	r := NewRSSHelper(st, nil, config.FetcherConfig{
+		Health: config.HealthConfig{AlertAfter: 1, PauseAfter: 1},
+	})
+	alert := &recordAgent{}
+	r.SetAlertAgent(alert)
+	f := &failingFetcher{err: errors.New("boom")}
+	r.AddFeed("test", f, config.FeedConfig{URL: "https://example.com/rss"})
+	r.fetchOne("test")
+
+	// resume 命令恢复后抓取成功，仍应发送恢复通知
+	h, _ := st.Health("test")
+	if err := st.SaveHealth("test", h.Resume()); err != nil {
+		t.Fatal(err)
+	}
+	f.err = nil
+	if result := r.fetchOne("test"); result == fetchResultSkipped {
+		t.Fatal("恢复后不应跳过抓取")
+	}
+	if len(alert.sent) != 2 || alert.sent[1].Title != "源 test 已恢复" {
+		t.Fatalf("期望发送故障告警和恢复通知: %+v", alert.sent)
+	}
+	if h, _ = st.Health("test"); h.Alerted || h.Paused {
+		t.Fatalf("恢复通知后健康状况不符合预期: %+v", h)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := newHealthPolicy(config.HealthConfig{BackoffBase: 1, BackoffMax: 5})
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := p.backoff.next(i + 1); got != w {
+			t.Errorf("失败 %d 次: 期望 %s，实际 %s", i+1, w, got)
+		}
+	}
+}
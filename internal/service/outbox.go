@@ -0,0 +1,226 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+const (
+	defaultOutboxInterval    = 30 * time.Second
+	defaultOutboxMaxAttempts = 5
+	defaultOutboxBackoffBase = time.Minute
+	defaultOutboxBackoffMax  = time.Hour
+)
+
+// Outbox 持久化发件箱，消息先落盘再发送，失败后按退避重试，超过次数移入死信队列
+type Outbox struct {
+	store       store.Store
+	agents      map[string]agent.Agent
+	interval    time.Duration
+	maxAttempts int
+	backoff     backoffPolicy
+
+	mu       sync.Mutex          // 保护 inflight，不在发送期间持有
+	inflight map[uint64]struct{} // 正在投递的消息，避免同一条消息被并发投递
+	stopChan chan bool
+}
+
+// NewOutbox 创建发件箱
+func NewOutbox(st store.Store, cfg config.OutboxConfig) *Outbox {
+	o := &Outbox{
+		store:       st,
+		agents:      make(map[string]agent.Agent),
+		interval:    defaultOutboxInterval,
+		maxAttempts: defaultOutboxMaxAttempts,
+		backoff: backoffPolicy{
+			base: defaultOutboxBackoffBase,
+			max:  defaultOutboxBackoffMax,
+		},
+		inflight: make(map[uint64]struct{}),
+		stopChan: make(chan bool),
+	}
+	if cfg.Interval > 0 {
+		o.interval = time.Duration(cfg.Interval) * time.Second
+	}
+	if cfg.MaxAttempts > 0 {
+		o.maxAttempts = cfg.MaxAttempts
+	}
+	if cfg.BackoffBase > 0 {
+		o.backoff.base = time.Duration(cfg.BackoffBase) * time.Second
+	}
+	if cfg.BackoffMax > 0 {
+		o.backoff.max = time.Duration(cfg.BackoffMax) * time.Second
+	}
+	return o
+}
+
+// Register 注册发送渠道
+func (o *Outbox) Register(channel string, ag agent.Agent) {
+	o.agents[channel] = ag
+}
+
+// Agent 返回写入发件箱的代理，供抓取器在 Complete 中使用
+func (o *Outbox) Agent(feed constants.AgentName, channel string) agent.Agent {
+	return &outboxAgent{
+		outbox:  o,
+		feed:    feed,
+		channel: channel,
+	}
+}
+
+// Enqueue 将消息写入发件箱并立即尝试投递，写入成功即返回 nil
+func (o *Outbox) Enqueue(feed constants.AgentName, channel string, data model.FeedData) error {
+	now := time.Now()
+	msg := store.OutboxMessage{
+		Feed:        feed,
+		Channel:     channel,
+		Data:        data,
+		CreatedAt:   now,
+		NextAttempt: now,
+	}
+	if err := o.store.Enqueue(&msg); err != nil {
+		return fmt.Errorf("写入发件箱失败: %v", err)
+	}
+	o.mu.Lock()
+	claimed := o.claim(msg.ID)
+	o.mu.Unlock()
+	// 已被并发的 Deliver 认领时由它投递
+	if claimed {
+		o.deliver(msg)
+	}
+	return nil
+}
+
+// Deliver 投递所有到期且不在投递中的消息
+func (o *Outbox) Deliver() {
+	// 读取和认领在同一临界区内完成，已投递完成的消息在释放认领前已从发件箱删除，不会被重复投递
+	o.mu.Lock()
+	messages, err := o.store.PendingMessages()
+	if err != nil {
+		o.mu.Unlock()
+		log.Error("读取发件箱失败: %v", err)
+		return
+	}
+	now := time.Now()
+	var due []store.OutboxMessage
+	for _, msg := range messages {
+		if !msg.NextAttempt.After(now) && o.claim(msg.ID) {
+			due = append(due, msg)
+		}
+	}
+	o.mu.Unlock()
+
+	for _, msg := range due {
+		o.deliver(msg)
+	}
+}
+
+// claim 认领消息，已在投递中时返回 false，调用方需持有 o.mu
+func (o *Outbox) claim(id uint64) bool {
+	if _, ok := o.inflight[id]; ok {
+		return false
+	}
+	o.inflight[id] = struct{}{}
+	return true
+}
+
+// deliver 投递已认领的单条消息，更新发件箱后释放认领。发送时不持有 o.mu，慢渠道不会阻塞其他消息
+func (o *Outbox) deliver(msg store.OutboxMessage) {
+	defer func() {
+		o.mu.Lock()
+		delete(o.inflight, msg.ID)
+		o.mu.Unlock()
+	}()
+
+	ag, ok := o.agents[msg.Channel]
+	var err error
+	if !ok {
+		err = fmt.Errorf("未找到发送渠道 %s", msg.Channel)
+	} else {
+		err = ag.Send(msg.Data)
+	}
+
+	delivery := store.Delivery{
+		Feed:    msg.Feed,
+		Channel: msg.Channel,
+		SentAt:  time.Now(),
+	}
+	for _, item := range msg.Data.Items {
+		delivery.ItemKeys = append(delivery.ItemKeys, item.Key())
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	if err := o.store.RecordDelivery(delivery); err != nil {
+		log.Error("记录发送结果失败 %s: %v", msg.Feed, err)
+	}
+
+	if err == nil {
+		if err := o.store.RemoveMessage(msg.ID); err != nil {
+			log.Error("删除已发送消息失败 %d: %v", msg.ID, err)
+		}
+		return
+	}
+
+	msg.Attempts++
+	msg.LastError = err.Error()
+	if msg.Attempts >= o.maxAttempts {
+		log.Error("消息 %d 发送失败 %d 次，移入死信队列 %s: %v", msg.ID, msg.Attempts, msg.Feed, err)
+		if err := o.store.MoveToDeadLetter(msg); err != nil {
+			log.Error("移入死信队列失败 %d: %v", msg.ID, err)
+		}
+		return
+	}
+	msg.NextAttempt = time.Now().Add(o.backoff.next(msg.Attempts))
+	log.Error("消息 %d 发送失败 %s，将于 %s 重试: %v", msg.ID, msg.Feed, msg.NextAttempt.Format(time.DateTime), err)
+	if err := o.store.UpdateMessage(msg); err != nil {
+		log.Error("更新发件箱失败 %d: %v", msg.ID, err)
+	}
+}
+
+// StartSchedule 启动定时重试
+func (o *Outbox) StartSchedule() {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			o.Deliver()
+		case <-o.stopChan:
+			return
+		}
+	}
+}
+
+// Stop 停止定时重试
+func (o *Outbox) Stop() {
+	o.stopChan <- true
+}
+
+// outboxAgent 将消息写入发件箱的代理，格式化在入队前完成
+type outboxAgent struct {
+	outbox    *Outbox
+	feed      constants.AgentName
+	channel   string
+	formatter agent.DataFormatter
+}
+
+func (a *outboxAgent) Send(data model.FeedData) error {
+	if a.formatter != nil {
+		a.formatter(&data)
+	}
+	return a.outbox.Enqueue(a.feed, a.channel, data)
+}
+
+func (a *outboxAgent) SetFormatter(formatter agent.DataFormatter) {
+	a.formatter = formatter
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+type flakyAgent struct {
+	err  error
+	sent int
+}
+
+func (a *flakyAgent) Send(data model.FeedData) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.sent++
+	return nil
+}
+
+func (a *flakyAgent) SetFormatter(formatter agent.DataFormatter) {}
+
+func TestOutbox(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	o := NewOutbox(st, config.OutboxConfig{MaxAttempts: 2, BackoffBase: 1})
+	ag := &flakyAgent{err: errors.New("network error")}
+	o.Register("test", ag)
+
+	data := model.FeedData{Items: []model.FeedItem{{GUID: "1"}}}
+	if err := o.Agent("feed", "test").Send(data); err != nil {
+		t.Fatalf("写入发件箱应成功: %v", err)
+	}
+	pending, _ := st.PendingMessages()
+	if len(pending) != 1 || pending[0].Attempts != 1 {
+		t.Fatalf("发送失败后应保留在发件箱: %+v", pending)
+	}
+
+	// 达到最大尝试次数后移入死信队列
+	pending[0].NextAttempt = pending[0].CreatedAt
+	st.UpdateMessage(pending[0])
+	o.Deliver()
+	pending, _ = st.PendingMessages()
+	dead, _ := st.DeadLetters()
+	if len(pending) != 0 || len(dead) != 1 {
+		t.Fatalf("期望移入死信队列: %d pending, %d dead", len(pending), len(dead))
+	}
+
+	// 重放后发送成功
+	ag.err = nil
+	if err := st.ReplayDeadLetter(dead[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	o.Deliver()
+	pending, _ = st.PendingMessages()
+	dead, _ = st.DeadLetters()
+	if ag.sent != 1 || len(pending) != 0 || len(dead) != 0 {
+		t.Fatalf("重放后发送不符合预期: sent %d, %d pending, %d dead", ag.sent, len(pending), len(dead))
+	}
+}
+
+// blockingAgent 发送时阻塞，直到 release 被关闭
+type blockingAgent struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (a *blockingAgent) Send(data model.FeedData) error {
+	close(a.started)
+	<-a.release
+	return nil
+}
+
+func (a *blockingAgent) SetFormatter(formatter agent.DataFormatter) {}
+
+func TestOutboxSlowChannel(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	o := NewOutbox(st, config.OutboxConfig{})
+	slow := &blockingAgent{started: make(chan struct{}), release: make(chan struct{})}
+	fast := &flakyAgent{}
+	o.Register("slow", slow)
+	o.Register("fast", fast)
+
+	data := model.FeedData{Items: []model.FeedItem{{GUID: "1"}}}
+	slowDone := make(chan struct{})
+	go func() {
+		o.Enqueue("feed", "slow", data)
+		close(slowDone)
+	}()
+	<-slow.started
+
+	// 慢渠道发送中，其他渠道的消息和定时投递不应被阻塞
+	done := make(chan struct{})
+	go func() {
+		o.Enqueue("feed", "fast", data)
+		o.Deliver()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("慢渠道阻塞了其他消息的投递")
+	}
+	if fast.sent != 1 {
+		t.Fatalf("快渠道期望发送 1 次，实际 %d", fast.sent)
+	}
+	close(slow.release)
+	<-slowDone
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/filter"
+	"github.com/weirwei/rss-agent/internal/log"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+// Router 位于抓取和发送之间，按内容将新增条目路由到渠道：每个渠道的条目合并为一条消息，
+// 同一条目不会重复发送到同一个渠道
+type Router struct {
+	store          store.SeenStore
+	outbox         *Outbox
+	digest         *DigestHelper
+	rules          []route
+	defaultChannel []string
+	feeds          map[constants.AgentName]routeFeed
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex // 每个渠道一把锁，避免并发抓取的源重复发送同一条目
+}
+
+// route 编译后的路由规则
+type route struct {
+	rule     *filter.Rule
+	channels []string
+	feeds    map[constants.AgentName]bool
+}
+
+// routeFeed 源的默认渠道和格式化器
+type routeFeed struct {
+	channels  []string
+	formatter agent.DataFormatter
+}
+
+// NewRouter 创建路由器，路由到的渠道通过发件箱发送
+func NewRouter(st store.SeenStore, outbox *Outbox, cfg config.RoutingConfig) (*Router, error) {
+	r := &Router{
+		store:          st,
+		outbox:         outbox,
+		defaultChannel: cfg.Default,
+		feeds:          make(map[constants.AgentName]routeFeed),
+		locks:          make(map[string]*sync.Mutex),
+	}
+	for i, routeCfg := range cfg.Rules {
+		rule, err := filter.NewRule(routeCfg.FilterRule, fmt.Sprintf("route-%d", i+1))
+		if err != nil {
+			return nil, err
+		}
+		rt := route{rule: rule, channels: routeCfg.Channels}
+		if len(routeCfg.Feeds) > 0 {
+			rt.feeds = make(map[constants.AgentName]bool)
+			for _, feed := range routeCfg.Feeds {
+				rt.feeds[constants.AgentName(feed)] = true
+			}
+		}
+		r.rules = append(r.rules, rt)
+	}
+	return r, nil
+}
+
+// SetDigest 设置汇总发送服务，路由到汇总渠道的条目写入该渠道的汇总队列
+func (r *Router) SetDigest(digest *DigestHelper) {
+	r.digest = digest
+}
+
+// Channels 返回所有路由规则和默认路由用到的渠道
+func (r *Router) Channels() []string {
+	var channels []string
+	for _, rt := range r.rules {
+		channels = append(channels, rt.channels...)
+	}
+	return append(channels, r.defaultChannel...)
+}
+
+// AddFeed 注册源，channels 为未匹配任何规则且没有配置默认路由时使用的渠道
+func (r *Router) AddFeed(feed constants.AgentName, channels []string, formatter agent.DataFormatter) {
+	r.feeds[feed] = routeFeed{channels: channels, formatter: formatter}
+}
+
+// Agent 返回源使用的代理，供抓取器在 Complete 中使用
+func (r *Router) Agent(feed constants.AgentName) agent.Agent {
+	return &routerAgent{router: r, feed: feed}
+}
+
+// Route 将条目按渠道分组后分别写入发件箱
+func (r *Router) Route(feed constants.AgentName, data model.FeedData) error {
+	now := time.Now()
+	var order []string
+	groups := make(map[string][]model.FeedItem)
+	for _, item := range data.Items {
+		for _, channel := range r.destinations(feed, item, now) {
+			if _, ok := groups[channel]; !ok {
+				order = append(order, channel)
+			}
+			groups[channel] = append(groups[channel], item)
+		}
+	}
+
+	var errs []error
+	for _, channel := range order {
+		if err := r.send(feed, channel, data, groups[channel]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// destinations 返回条目要发送到的渠道，已去重
+func (r *Router) destinations(feed constants.AgentName, item model.FeedItem, now time.Time) []string {
+	var channels []string
+	for _, rt := range r.rules {
+		if rt.feeds != nil && !rt.feeds[feed] {
+			continue
+		}
+		if rt.rule.Match(item, now) {
+			channels = append(channels, rt.channels...)
+		}
+	}
+	if len(channels) == 0 {
+		channels = r.defaultChannel
+	}
+	if len(channels) == 0 {
+		channels = r.feeds[feed].channels
+	}
+
+	seen := make(map[string]bool, len(channels))
+	unique := channels[:0:0]
+	for _, channel := range channels {
+		if !seen[channel] {
+			seen[channel] = true
+			unique = append(unique, channel)
+		}
+	}
+	return unique
+}
+
+// send 跳过已发送到该渠道的条目，其余合并为一条消息写入发件箱
+func (r *Router) send(feed constants.AgentName, channel string, data model.FeedData, items []model.FeedItem) error {
+	lock := r.lock(channel)
+	lock.Lock()
+	defer lock.Unlock()
+
+	name := deliveredName(channel)
+	items, err := r.store.FilterNew(name, items)
+	if err != nil {
+		return fmt.Errorf("筛选未发送条目失败: %v", err)
+	}
+	if len(items) == 0 {
+		log.Info("源 %s 的条目均已发送到渠道 %s", feed, channel)
+		return nil
+	}
+	// 格式化器会修改条目，复制一份以免影响已发送记录的键
+	data.Items = append([]model.FeedItem(nil), items...)
+	ag := r.outbox.Agent(feed, channel)
+	if r.digest != nil && r.digest.HasChannel(channel) {
+		ag = r.digest.Agent(feed, channel)
+	}
+	ag.SetFormatter(r.feeds[feed].formatter)
+	if err := ag.Send(data); err != nil {
+		return err
+	}
+	log.Info("源 %s 路由 %d 条到渠道 %s", feed, len(items), channel)
+	return r.store.MarkSeen(name, items)
+}
+
+func (r *Router) lock(channel string) *sync.Mutex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lock, ok := r.locks[channel]
+	if !ok {
+		lock = &sync.Mutex{}
+		r.locks[channel] = lock
+	}
+	return lock
+}
+
+// routerAgent 将源的增量数据交给路由器
+type routerAgent struct {
+	router *Router
+	feed   constants.AgentName
+}
+
+func (a *routerAgent) Send(data model.FeedData) error {
+	return a.router.Route(a.feed, data)
+}
+
+// SetFormatter 格式化器通过 Router.AddFeed 按源配置
+func (a *routerAgent) SetFormatter(formatter agent.DataFormatter) {
+}
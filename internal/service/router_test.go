@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/agent"
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+type recordingAgent struct {
+	sent [][]string
+}
+
+func (a *recordingAgent) Send(data model.FeedData) error {
+	var guids []string
+	for _, item := range data.Items {
+		guids = append(guids, item.GUID)
+	}
+	a.sent = append(a.sent, guids)
+	return nil
+}
+
+func (a *recordingAgent) SetFormatter(formatter agent.DataFormatter) {}
+
+func TestRouter(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	o := NewOutbox(st, config.OutboxConfig{})
+	agents := map[string]*recordingAgent{"ai": {}, "infra": {}, "rss": {}, "blogs": {}}
+	for name, ag := range agents {
+		o.Register(name, ag)
+	}
+	r, err := NewRouter(st, o, config.RoutingConfig{
+		Rules: []config.RouteConfig{
+			{Channels: []string{"ai"}, FilterRule: config.FilterRule{Keywords: []string{"llm"}}},
+			{Channels: []string{"infra", "ai"}, FilterRule: config.FilterRule{Keywords: []string{"kubernetes"}}},
+			{Channels: []string{"infra"}, Feeds: []string{"other"}, FilterRule: config.FilterRule{Keywords: []string{"go"}}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AddFeed("blogs", []string{"blogs"}, nil)
+
+	data := model.FeedData{Feed: "blogs", Items: []model.FeedItem{
+		{GUID: "1", Title: "LLM on Kubernetes"},
+		{GUID: "2", Title: "Kubernetes 1.30"},
+		{GUID: "3", Title: "Go tips"},
+	}}
+	if err := r.Agent("blogs").Send(data); err != nil {
+		t.Fatal(err)
+	}
+	// 每个渠道一条消息，同一条目在一个渠道只出现一次；未匹配的条目走源的默认渠道
+	want := map[string]string{"ai": "[[1 2]]", "infra": "[[1 2]]", "blogs": "[[3]]", "rss": "[]"}
+	for name, ag := range agents {
+		if got := fmt.Sprint(ag.sent); got != want[name] {
+			t.Fatalf("渠道 %s 期望 %s，实际 %s", name, want[name], got)
+		}
+	}
+
+	// 其他源中的相同条目不会再次发送到同一个渠道
+	r.AddFeed("other", []string{"rss"}, nil)
+	data.Feed = "other"
+	data.Items = append(data.Items, model.FeedItem{GUID: "4", Title: "LLM news"})
+	if err := r.Agent("other").Send(data); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{"ai": "[[1 2] [4]]", "infra": "[[1 2] [3]]", "blogs": "[[3]]", "rss": "[]"}
+	for name, ag := range agents {
+		if got := fmt.Sprint(ag.sent); got != want[name] {
+			t.Fatalf("渠道 %s 期望 %s，实际 %s", name, want[name], got)
+		}
+	}
+}
@@ -1,127 +1,346 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"math/rand"
+	"sync"
 	"time"
 
-	jsoniter "github.com/json-iterator/go"
+	"github.com/robfig/cron/v3"
+	"github.com/weirwei/rss-agent/internal/agent"
 	"github.com/weirwei/rss-agent/internal/config"
 	"github.com/weirwei/rss-agent/internal/constants"
 	"github.com/weirwei/rss-agent/internal/fetcher"
+	"github.com/weirwei/rss-agent/internal/filter"
+	"github.com/weirwei/rss-agent/internal/httpclient"
 	"github.com/weirwei/rss-agent/internal/log"
 	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/sanitize"
+	"github.com/weirwei/rss-agent/internal/store"
+)
+
+const (
+	defaultWorkers     = 4
+	defaultFeedTimeout = 60 * time.Second
 )
 
 // RSSHelper RSS助手服务
 type RSSHelper struct {
-	feeds     map[constants.AgentName]config.FeedConfig
-	fetchers  map[constants.AgentName]fetcher.FeedFetcher
-	outputDir string
-	stopChan  chan bool
+	feeds       map[constants.AgentName]config.FeedConfig
+	fetchers    map[constants.AgentName]fetcher.FeedFetcher
+	filters     map[constants.AgentName]*filter.Filter
+	store       store.Store
+	client      *httpclient.Client
+	workers     int
+	feedTimeout time.Duration
+	health      healthPolicy
+	alert       agent.Agent
+	stopChan    chan bool
 }
 
-// NewRSSHelper 创建新的RSS助手实例
-func NewRSSHelper(outputDir string) *RSSHelper {
-	if outputDir == "" {
-		outputDir = "rss_output"
-	}
+// fetchResult 单个源的抓取结果
+type fetchResult int
 
-	// 创建输出目录
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		log.Error("创建输出目录失败: %v", err)
-	}
+const (
+	fetchResultFetched   fetchResult = iota // 有新内容
+	fetchResultUnchanged                    // 未变化或没有新增条目
+	fetchResultFailed                       // 抓取失败
+	fetchResultSkipped                      // 暂停或退避中
+)
 
+// NewRSSHelper 创建新的RSS助手实例，client 为抓取器共享的 HTTP 层
+func NewRSSHelper(st store.Store, client *httpclient.Client, cfg config.FetcherConfig) *RSSHelper {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	feedTimeout := defaultFeedTimeout
+	if cfg.Timeout > 0 {
+		feedTimeout = time.Duration(cfg.Timeout) * time.Second
+	}
 	return &RSSHelper{
-		feeds:     make(map[constants.AgentName]config.FeedConfig),
-		fetchers:  make(map[constants.AgentName]fetcher.FeedFetcher),
-		outputDir: outputDir,
-		stopChan:  make(chan bool),
+		feeds:       make(map[constants.AgentName]config.FeedConfig),
+		fetchers:    make(map[constants.AgentName]fetcher.FeedFetcher),
+		filters:     make(map[constants.AgentName]*filter.Filter),
+		store:       st,
+		client:      client,
+		workers:     workers,
+		feedTimeout: feedTimeout,
+		health:      newHealthPolicy(cfg.Health),
+		stopChan:    make(chan bool),
 	}
 }
 
-// AddFeed 添加源
-func (r *RSSHelper) AddFeed(name constants.AgentName, fetcher fetcher.FeedFetcher, config config.FeedConfig) {
+// AddFeed 添加源，过滤规则无效时返回错误且不添加，避免源在没有过滤的情况下发送
+func (r *RSSHelper) AddFeed(name constants.AgentName, fetcher fetcher.FeedFetcher, config config.FeedConfig) error {
+	f, err := filter.New(config.Filter)
+	if err != nil {
+		return fmt.Errorf("源 %s 的过滤规则无效: %v", name, err)
+	}
 	r.feeds[name] = config
 	r.fetchers[name] = fetcher
+	r.filters[name] = f
+	return nil
 }
 
 // FetchAllFeeds 抓取所有源
 func (r *RSSHelper) FetchAllFeeds() {
-	for name, config := range r.feeds {
-		url := config.URL
-		if config.Dynamic {
-			currentDate := time.Now().Format(config.Format)
-			url = strings.Replace(config.Template, "{{date}}", currentDate, -1)
-		}
-		f, ok := r.fetchers[name]
-		if !ok {
-			log.Error("未找到 fetcher %s", name)
-			continue
-		}
-		if feed, err := f.Fetch(url); err == nil {
-			var oldFeed model.FeedData
-			file, err := os.ReadFile(fmt.Sprintf("%s/%s.json", r.outputDir, name))
-			if err != nil && !os.IsNotExist(err) {
-				log.Error("读取文件失败 %s: %v", name, err)
-				continue
-			}
-			if len(file) > 0 {
-				err = jsoniter.Unmarshal(file, &oldFeed)
-				if err != nil {
-					log.Error("解析旧数据失败 %s: %v", name, err)
+	names := make([]constants.AgentName, 0, len(r.feeds))
+	for name := range r.feeds {
+		names = append(names, name)
+	}
+	r.fetchFeeds(names)
+}
+
+// fetchFeeds 使用有界的工作池并发抓取指定的源
+func (r *RSSHelper) fetchFeeds(feeds []constants.AgentName) {
+	start := time.Now()
+	names := make(chan constants.AgentName)
+	var (
+		wg                                  sync.WaitGroup
+		mu                                  sync.Mutex
+		fetched, unchanged, failed, skipped int
+	)
+	for i := 0; i < r.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				result := r.fetchOne(name)
+				mu.Lock()
+				switch result {
+				case fetchResultFetched:
+					fetched++
+				case fetchResultUnchanged:
+					unchanged++
+				case fetchResultSkipped:
+					skipped++
+				default:
+					failed++
 				}
+				mu.Unlock()
 			}
-			// 最后更新时间相同，不更新
-			if feed.LastUpdated.Equal(oldFeed.LastUpdated) {
-				continue
-			}
-			// 保存到JSON
-			if data, err := json.MarshalIndent(feed, "", "  "); err == nil {
-				outputFile := filepath.Join(r.outputDir, string(name)+".json")
-				os.WriteFile(outputFile, data, 0644)
-			}
-			// 用增量数据执行后处理
-			latestFeed := fetcher.LatestFeed(oldFeed, *feed)
-			err = f.Complete(&latestFeed)
-			if err != nil {
-				log.Error("完成抓取失败 %s: %v", name, err)
-				continue
-			}
-		} else {
-			log.Error("抓取源 %s 失败: %v", name, err)
+		}()
+	}
+	for _, name := range feeds {
+		names <- name
+	}
+	close(names)
+	wg.Wait()
+
+	log.Info("本轮抓取完成: 更新 %d，未变化 %d，失败 %d，跳过 %d，耗时 %s",
+		fetched, unchanged, failed, skipped, time.Since(start).Round(time.Millisecond))
+}
+
+// fetchOne 在时限内抓取单个源并记录抓取结果
+func (r *RSSHelper) fetchOne(name constants.AgentName) fetchResult {
+	config := r.feeds[name]
+	urls := []string{config.URL}
+	if config.Dynamic != nil {
+		var err error
+		if urls, err = config.Dynamic.URLs(time.Now()); err != nil {
+			log.Error("生成源 %s 的地址失败: %v", name, err)
+			return fetchResultFailed
+		}
+	}
+	f, ok := r.fetchers[name]
+	if !ok {
+		log.Error("未找到 fetcher %s", name)
+		return fetchResultFailed
+	}
+	if r.shouldSkip(name) {
+		return fetchResultSkipped
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.feedTimeout)
+	defer cancel()
+
+	run := store.FetchRun{
+		Feed:      name,
+		URL:       urls[0],
+		StartedAt: time.Now(),
+	}
+	result := fetchResultFetched
+	err := r.fetchFeed(ctx, name, urls, f, &run)
+	switch {
+	case errors.Is(err, httpclient.ErrNotModified):
+		log.Info("源 %s 未变化", name)
+		result = fetchResultUnchanged
+	case err != nil:
+		log.Error("抓取源 %s 失败: %v", name, err)
+		run.Error = err.Error()
+		result = fetchResultFailed
+	case run.NewItems == 0:
+		result = fetchResultUnchanged
+	}
+	run.Duration = time.Since(run.StartedAt)
+	if r.client != nil {
+		stats := r.client.Stats(run.URL)
+		log.Info("条件请求统计 %s: 命中 %d，未命中 %d", name, stats.Hits, stats.Misses)
+	}
+	if err := r.store.RecordFetchRun(run); err != nil {
+		log.Error("记录抓取结果失败 %s: %v", name, err)
+	}
+	return result
+}
+
+// fetchFeed 依次尝试候选地址抓取单个源，保存快照并对新增条目执行后处理
+func (r *RSSHelper) fetchFeed(ctx context.Context, name constants.AgentName, urls []string, f fetcher.FeedFetcher, run *store.FetchRun) error {
+	var feed *model.FeedData
+	var err error
+	for i, url := range urls {
+		run.URL = url
+		feed, err = f.Fetch(ctx, url)
+		if err != nil && !errors.Is(err, httpclient.ErrNotModified) {
+			r.forget(url)
+		}
+		if err == nil || errors.Is(err, httpclient.ErrNotModified) || ctx.Err() != nil {
+			break
 		}
+		if i < len(urls)-1 {
+			log.Info("源 %s 抓取 %s 失败，尝试下一个地址: %v", name, url, err)
+		}
+	}
+	url := run.URL
+	r.updateHealth(name, err)
+	if err != nil {
+		return err
+	}
+	sanitizeCfg := r.feeds[name].Sanitize
+	sanitize.Feed(feed, sanitizeCfg.Format, sanitizeCfg.MaxLength)
+	run.Items = len(feed.Items)
+	feed.Feed = string(name)
+	// 从已见条目存储中筛选新增条目。先于保存快照，旧版本升级时以上次的快照建立已见条目记录
+	newItems, err := r.store.FilterNew(name, feed.Items)
+	if err != nil {
+		r.forget(url)
+		return fmt.Errorf("筛选新增条目失败: %v", err)
+	}
+	if err := r.store.SaveFeed(name, *feed); err != nil {
+		log.Error("保存源数据失败 %s: %v", name, err)
+	}
+	run.NewItems = len(newItems)
+	if len(newItems) == 0 {
+		return nil
+	}
+	// 过滤掉不关心的条目，被过滤的条目同样记为已见
+	items, stats := r.filters[name].Apply(newItems, time.Now())
+	for _, stat := range stats {
+		log.Info("源 %s 过滤规则 %s 丢弃 %d 条", name, stat.Rule, stat.Dropped)
 	}
+	if len(items) == 0 {
+		if err := r.store.MarkSeen(name, newItems); err != nil {
+			log.Error("记录已见条目失败 %s: %v", name, err)
+		}
+		return nil
+	}
+	// 用增量数据执行后处理。后处理可能就地格式化条目，复制一份以免改变 newItems 的键
+	latestFeed := model.FeedData{
+		Feed:        feed.Feed,
+		Title:       feed.Title,
+		Description: feed.Description,
+		LastUpdated: feed.LastUpdated,
+		Items:       append([]model.FeedItem(nil), items...),
+	}
+	if err := f.Complete(&latestFeed); err != nil {
+		r.forget(url)
+		return fmt.Errorf("完成抓取失败: %v", err)
+	}
+	if err := r.store.MarkSeen(name, newItems); err != nil {
+		log.Error("记录已见条目失败 %s: %v", name, err)
+	}
+	return nil
+}
 
+// forget 清除地址的校验信息。HTTP 层在收到 200 时就保存了校验信息，之后解析或处理失败时必须清除，
+// 否则下次请求返回 304，未处理的内容会被跳过，失败也会被误记为恢复
+func (r *RSSHelper) forget(url string) {
+	if r.client != nil {
+		r.client.Forget(url)
+	}
 }
 
-// StartSchedule 启动定时任务
+// StartSchedule 启动定时任务，每个源按自己的间隔或 cron 表达式调度，
+// 未单独配置的源使用 intervalMinutes 作为默认间隔
 func (r *RSSHelper) StartSchedule(intervalMinutes int) {
-	if intervalMinutes <= 0 {
-		log.Error("定时任务间隔必须大于0分钟")
+	schedules := make(map[constants.AgentName]cron.Schedule)
+	nextRun := make(map[constants.AgentName]time.Time)
+	now := time.Now()
+	for name, config := range r.feeds {
+		schedule, err := feedSchedule(config, intervalMinutes)
+		if err != nil {
+			log.Error("源 %s 的调度配置无效: %v", name, err)
+			continue
+		}
+		schedules[name] = schedule
+		nextRun[name] = nextRunTime(schedule, config.Jitter, now)
+		log.Info("源 %s 下次抓取时间：%s", name, nextRun[name].Format(time.DateTime))
+	}
+	if len(schedules) == 0 {
+		log.Error("没有可调度的源")
 		return
 	}
 
-	ticker := time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
-	defer ticker.Stop()
-
-	log.Info("开始定时任务，间隔时间：%d分钟", intervalMinutes)
+	log.Info("开始定时任务，默认间隔时间：%d分钟", intervalMinutes)
 
 	for {
+		earliest := time.Time{}
+		for _, t := range nextRun {
+			if earliest.IsZero() || t.Before(earliest) {
+				earliest = t
+			}
+		}
+		timer := time.NewTimer(time.Until(earliest))
 		select {
-		case <-ticker.C:
-			log.Info("执行定时抓取任务...")
-			r.FetchAllFeeds()
+		case <-timer.C:
+			now := time.Now()
+			var due []constants.AgentName
+			for name, t := range nextRun {
+				if !t.After(now) {
+					due = append(due, name)
+				}
+			}
+			log.Info("执行定时抓取任务，共 %d 个源...", len(due))
+			r.fetchFeeds(due)
+			now = time.Now()
+			for _, name := range due {
+				nextRun[name] = nextRunTime(schedules[name], r.feeds[name].Jitter, now)
+				log.Info("源 %s 下次抓取时间：%s", name, nextRun[name].Format(time.DateTime))
+			}
 		case <-r.stopChan:
+			timer.Stop()
 			log.Info("停止定时任务")
 			return
 		}
 	}
 }
 
+// feedSchedule 解析源的调度配置：cron 表达式优先，其次是源自己的间隔，最后是默认间隔
+func feedSchedule(config config.FeedConfig, defaultMinutes int) (cron.Schedule, error) {
+	if config.Cron != "" {
+		return cron.ParseStandard(config.Cron)
+	}
+	interval := config.Interval
+	if interval <= 0 {
+		interval = time.Duration(defaultMinutes) * time.Minute
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("定时任务间隔必须大于0分钟")
+	}
+	return cron.Every(interval), nil
+}
+
+// nextRunTime 计算下次运行时间，并加上 [0, jitter) 的随机抖动
+func nextRunTime(schedule cron.Schedule, jitter time.Duration, now time.Time) time.Time {
+	next := schedule.Next(now)
+	if jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(jitter))))
+	}
+	return next
+}
+
 // Stop 停止定时任务
 func (r *RSSHelper) Stop() {
 	r.stopChan <- true
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/fetcher"
+	"github.com/weirwei/rss-agent/internal/httpclient"
+	"github.com/weirwei/rss-agent/internal/model"
+	"github.com/weirwei/rss-agent/internal/store"
+	"github.com/weirwei/rss-agent/internal/urltmpl"
+)
+
+type fakeFetcher struct {
+	mu        sync.Mutex
+	items     []model.FeedItem
+	completed [][]model.FeedItem
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, url string) (*model.FeedData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &model.FeedData{Title: url, Items: append([]model.FeedItem(nil), f.items...)}, nil
+}
+
+func (f *fakeFetcher) Complete(data *model.FeedData) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.completed = append(f.completed, data.Items)
+	return nil
+}
+
+func TestFetchAllFeeds(t *testing.T) {
+	r := NewRSSHelper(store.NewJSONStore(t.TempDir()), nil, config.FetcherConfig{Workers: 3})
+	fetchers := make([]*fakeFetcher, 10)
+	for i := range fetchers {
+		fetchers[i] = &fakeFetcher{items: []model.FeedItem{{GUID: "1", Title: "a"}}}
+		name := constants.AgentName(fmt.Sprintf("feed-%d", i))
+		r.AddFeed(name, fetchers[i], config.FeedConfig{URL: string(name)})
+	}
+	r.FetchAllFeeds()
+
+	// 第二轮只有新增条目会被处理
+	for _, f := range fetchers {
+		f.items = append([]model.FeedItem{{GUID: "2", Title: "a"}}, f.items...)
+	}
+	r.FetchAllFeeds()
+
+	for i, f := range fetchers {
+		if len(f.completed) != 2 || len(f.completed[1]) != 1 || f.completed[1][0].GUID != "2" {
+			t.Fatalf("feed-%d 处理结果不符合预期: %+v", i, f.completed)
+		}
+	}
+}
+
+func TestFetchFeedFilter(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	r := NewRSSHelper(st, nil, config.FetcherConfig{})
+	f := &fakeFetcher{items: []model.FeedItem{
+		{GUID: "1", Title: "Go 1.23 released"},
+		{GUID: "2", Title: "NFT drops"},
+		{GUID: "3", Title: "Rust news"},
+	}}
+	err := r.AddFeed("blogs", f, config.FeedConfig{URL: "blogs", Filter: config.FilterConfig{
+		Exclude: []config.FilterRule{{Keywords: []string{"nft"}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 过滤规则无效时不添加源
+	err = r.AddFeed("invalid", f, config.FeedConfig{URL: "invalid", Filter: config.FilterConfig{
+		Exclude: []config.FilterRule{{Regex: "("}},
+	}})
+	if err == nil {
+		t.Fatal("无效的过滤规则应当报错")
+	}
+	r.FetchAllFeeds()
+
+	if len(f.completed) != 1 || len(f.completed[0]) != 2 {
+		t.Fatalf("过滤结果不符合预期: %+v", f.completed)
+	}
+	// 被过滤的条目同样记为已见，不会再次处理
+	r.FetchAllFeeds()
+	if len(f.completed) != 1 {
+		t.Fatalf("被过滤的条目不应再次处理: %+v", f.completed)
+	}
+}
+
+func TestFetchFeedUpgrade(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	// 旧版本只保存了快照，没有已见条目记录
+	if err := st.SaveFeed("blogs", model.FeedData{Items: []model.FeedItem{{GUID: "1"}, {GUID: "2"}}}); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRSSHelper(st, nil, config.FetcherConfig{})
+	f := &fakeFetcher{items: []model.FeedItem{{GUID: "3"}, {GUID: "1"}, {GUID: "2"}}}
+	r.AddFeed("blogs", f, config.FeedConfig{URL: "blogs"})
+	// 新源没有快照，首次抓取的条目都是新增
+	other := &fakeFetcher{items: []model.FeedItem{{GUID: "1"}}}
+	r.AddFeed("other", other, config.FeedConfig{URL: "other"})
+	r.FetchAllFeeds()
+
+	if len(f.completed) != 1 || len(f.completed[0]) != 1 || f.completed[0][0].GUID != "3" {
+		t.Fatalf("升级后只应处理快照之外的条目: %+v", f.completed)
+	}
+	if len(other.completed) != 1 || len(other.completed[0]) != 1 {
+		t.Fatalf("新源的首次抓取不符合预期: %+v", other.completed)
+	}
+}
+
+func TestFetchBadBodyForgetsValidators(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("<html>not a feed"))
+	}))
+	defer srv.Close()
+
+	st := store.NewJSONStore(t.TempDir())
+	client := httpclient.New(srv.Client(), st)
+	r := NewRSSHelper(st, client, config.FetcherConfig{})
+	r.AddFeed("broken", fetcher.NewRSSFetcher(client), config.FeedConfig{URL: srv.URL})
+
+	// 200 但内容无法解析，下次请求不能因 304 被当作未变化
+	for i := 1; i <= 2; i++ {
+		if result := r.fetchOne("broken"); result != fetchResultFailed {
+			t.Fatalf("第 %d 次抓取期望失败，实际 %d", i, result)
+		}
+		h, _ := st.Health("broken")
+		if h.ConsecutiveFailures != i {
+			t.Fatalf("第 %d 次抓取后失败次数不符合预期: %+v", i, h)
+		}
+		h.NextRetry = time.Time{}
+		st.SaveHealth("broken", h)
+	}
+}
+
+// mutatingFetcher 后处理时就地改写摘要，模拟格式化器
+type mutatingFetcher struct {
+	fakeFetcher
+}
+
+func (f *mutatingFetcher) Complete(data *model.FeedData) error {
+	for i := range data.Items {
+		data.Items[i].Summary = "formatted"
+	}
+	return f.fakeFetcher.Complete(data)
+}
+
+func TestFetchFeedCompleteMutation(t *testing.T) {
+	r := NewRSSHelper(store.NewJSONStore(t.TempDir()), nil, config.FetcherConfig{})
+	// 没有 GUID 和链接的条目按内容哈希去重
+	f := &mutatingFetcher{fakeFetcher{items: []model.FeedItem{{Title: "a", Summary: "raw"}}}}
+	r.AddFeed("blogs", f, config.FeedConfig{URL: "blogs"})
+	r.FetchAllFeeds()
+	r.FetchAllFeeds()
+
+	if len(f.completed) != 1 {
+		t.Fatalf("后处理改写条目后不应再次处理: %+v", f.completed)
+	}
+}
+
+// missingFetcher 只有 available 中的地址可以抓取，其余地址返回 404
+type missingFetcher struct {
+	fakeFetcher
+	available string
+	tried     []string
+}
+
+func (f *missingFetcher) Fetch(ctx context.Context, url string) (*model.FeedData, error) {
+	f.tried = append(f.tried, url)
+	if url != f.available {
+		return nil, errors.New("HTTP状态码错误: 404")
+	}
+	return f.fakeFetcher.Fetch(ctx, url)
+}
+
+func TestFetchDynamicFallback(t *testing.T) {
+	st := store.NewJSONStore(t.TempDir())
+	r := NewRSSHelper(st, nil, config.FetcherConfig{})
+	dynamic, err := urltmpl.Parse("https://example.com/daily-{{date}}/", "", "", []int{0, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	yesterday := "https://example.com/daily-" + time.Now().AddDate(0, 0, -1).Format("2006-01-02") + "/"
+	f := &missingFetcher{fakeFetcher: fakeFetcher{items: []model.FeedItem{{GUID: "1"}}}, available: yesterday}
+	r.AddFeed("daily", f, config.FeedConfig{Dynamic: dynamic})
+	r.FetchAllFeeds()
+
+	// 今天的页面还未发布时抓取昨天的页面
+	if len(f.tried) != 2 || f.tried[1] != yesterday || len(f.completed) != 1 {
+		t.Fatalf("动态地址回退不符合预期: %v %+v", f.tried, f.completed)
+	}
+	runs, err := st.FetchRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].URL != yesterday || runs[0].Error != "" {
+		t.Fatalf("抓取记录不符合预期: %+v", runs)
+	}
+}
+
+func TestFeedSchedule(t *testing.T) {
+	now := time.Date(2024, 1, 1, 8, 0, 0, 0, time.Local)
+	cases := []struct {
+		config config.FeedConfig
+		want   time.Time
+	}{
+		{config.FeedConfig{}, now.Add(30 * time.Minute)},
+		{config.FeedConfig{Interval: 5 * time.Minute}, now.Add(5 * time.Minute)},
+		{config.FeedConfig{Interval: 5 * time.Minute, Cron: "0 9 * * *"}, now.Add(time.Hour)},
+	}
+	for _, c := range cases {
+		schedule, err := feedSchedule(c.config, 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := nextRunTime(schedule, 0, now); !got.Equal(c.want) {
+			t.Errorf("%+v: 期望 %s，实际 %s", c.config, c.want, got)
+		}
+	}
+	if _, err := feedSchedule(config.FeedConfig{}, 0); err == nil {
+		t.Error("期望未配置间隔时返回错误")
+	}
+}
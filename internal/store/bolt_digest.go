@@ -0,0 +1,96 @@
+package store
+
+import (
+	"encoding/json"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketDigest = []byte("digest")
+
+func (s *BoltStore) AddDigestItems(channel string, items []DigestItem) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(bucketDigest).CreateBucketIfNotExists([]byte(channel))
+		if err != nil {
+			return err
+		}
+		queued := make(map[string]bool)
+		err = b.ForEach(func(_, v []byte) error {
+			var item DigestItem
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			queued[digestKey(item)] = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if queued[digestKey(item)] {
+				continue
+			}
+			queued[digestKey(item)] = true
+			if item.ID, err = b.NextSequence(); err != nil {
+				return err
+			}
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(itob(item.ID), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) DigestItems(channel string) ([]DigestItem, error) {
+	var items []DigestItem
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDigest).Bucket([]byte(channel))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var item DigestItem
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		})
+	})
+	return items, err
+}
+
+func (s *BoltStore) RemoveDigestItems(channel string, ids []uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDigest).Bucket([]byte(channel))
+		if b == nil {
+			return nil
+		}
+		for _, id := range ids {
+			if err := b.Delete(itob(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) DigestChannels() ([]string, error) {
+	var channels []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDigest).ForEach(func(k, _ []byte) error {
+			if b := tx.Bucket(bucketDigest).Bucket(k); b != nil {
+				if first, _ := b.Cursor().First(); first != nil {
+					channels = append(channels, string(k))
+				}
+			}
+			return nil
+		})
+	})
+	return channels, err
+}
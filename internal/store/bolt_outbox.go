@@ -0,0 +1,130 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketOutbox      = []byte("outbox")
+	bucketDeadLetters = []byte("dead_letters")
+)
+
+func (s *BoltStore) Enqueue(msg *OutboxMessage) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketOutbox)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		msg.ID = id
+		return putMessage(b, *msg)
+	})
+}
+
+func (s *BoltStore) PendingMessages() ([]OutboxMessage, error) {
+	return s.listMessages(bucketOutbox)
+}
+
+func (s *BoltStore) UpdateMessage(msg OutboxMessage) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketOutbox)
+		if b.Get(itob(msg.ID)) == nil {
+			return fmt.Errorf("消息不存在: %d", msg.ID)
+		}
+		return putMessage(b, msg)
+	})
+}
+
+func (s *BoltStore) RemoveMessage(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketOutbox).Delete(itob(id))
+	})
+}
+
+func (s *BoltStore) MoveToDeadLetter(msg OutboxMessage) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(bucketOutbox).Delete(itob(msg.ID)); err != nil {
+			return err
+		}
+		return putDeadLetter(tx.Bucket(bucketDeadLetters), msg)
+	})
+}
+
+func (s *BoltStore) AddDeadLetter(msg OutboxMessage) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putDeadLetter(tx.Bucket(bucketDeadLetters), msg)
+	})
+}
+
+// putDeadLetter 按死信队列自己的序号分配 ID，避免与导入的死信或发件箱重新分配的 ID 冲突
+func putDeadLetter(b *bolt.Bucket, msg OutboxMessage) error {
+	// 旧版本的死信沿用发件箱的 ID，序号从已有的最大 ID 之后开始
+	if last, _ := b.Cursor().Last(); last != nil && binary.BigEndian.Uint64(last) > b.Sequence() {
+		if err := b.SetSequence(binary.BigEndian.Uint64(last)); err != nil {
+			return err
+		}
+	}
+	id, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	msg.ID = id
+	return putMessage(b, msg)
+}
+
+func (s *BoltStore) DeadLetters() ([]OutboxMessage, error) {
+	return s.listMessages(bucketDeadLetters)
+}
+
+func (s *BoltStore) ReplayDeadLetter(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		dead := tx.Bucket(bucketDeadLetters)
+		data := dead.Get(itob(id))
+		if data == nil {
+			return fmt.Errorf("死信不存在: %d", id)
+		}
+		var msg OutboxMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return err
+		}
+		outbox := tx.Bucket(bucketOutbox)
+		newID, err := outbox.NextSequence()
+		if err != nil {
+			return err
+		}
+		msg.ID = newID
+		msg.Attempts = 0
+		msg.LastError = ""
+		msg.NextAttempt = time.Now()
+		if err := putMessage(outbox, msg); err != nil {
+			return err
+		}
+		return dead.Delete(itob(id))
+	})
+}
+
+func (s *BoltStore) listMessages(bucket []byte) ([]OutboxMessage, error) {
+	var list []OutboxMessage
+	err := s.forEachRecord(bucket, func(v []byte) error {
+		var msg OutboxMessage
+		if err := json.Unmarshal(v, &msg); err != nil {
+			return err
+		}
+		list = append(list, msg)
+		return nil
+	})
+	return list, err
+}
+
+func putMessage(b *bolt.Bucket, msg OutboxMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.Put(itob(msg.ID), data)
+}
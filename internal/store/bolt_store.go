@@ -0,0 +1,312 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketFeeds      = []byte("feeds")
+	bucketItems      = []byte("items")
+	bucketDeliveries = []byte("deliveries")
+	bucketFetchRuns  = []byte("fetch_runs")
+	bucketValidators = []byte("validators")
+	bucketHealth     = []byte("health")
+)
+
+// BoltStore 基于 bbolt 的单文件嵌入式数据库存储
+//
+// 桶结构:
+//
+//	feeds/<name>             源的最新快照
+//	items/<name>/<key>       已见条目
+//	deliveries/<seq>         发送记录
+//	fetch_runs/<seq>         抓取记录
+//	validators/<url>         条件请求校验信息
+//	health/<name>            源的健康状况
+//	outbox/<id>              发件箱
+//	dead_letters/<id>        死信队列
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore 打开或创建 bbolt 数据库
+func NewBoltStore(path string) (*BoltStore, error) {
+	if path == "" {
+		path = filepath.Join("rss_output", "rss-agent.db")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建数据库目录失败: %v", err)
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("数据库 %s 正被其他进程使用，bolt 存储同一时间只能由一个进程打开，请先停止常驻进程", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败 %s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketFeeds, bucketItems, bucketDeliveries, bucketFetchRuns, bucketValidators, bucketHealth, bucketOutbox, bucketDeadLetters, bucketDigest} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化数据库失败: %v", err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) FilterNew(name constants.AgentName, items []model.FeedItem) ([]model.FeedItem, error) {
+	var result []model.FeedItem
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketItems).Bucket([]byte(name))
+		batch := make(map[string]bool)
+		for _, item := range items {
+			key := item.Key()
+			if batch[key] || (b != nil && b.Get([]byte(key)) != nil) {
+				continue
+			}
+			batch[key] = true
+			result = append(result, item)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (s *BoltStore) MarkSeen(name constants.AgentName, items []model.FeedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	now := time.Now()
+	seen := make([]SeenItem, 0, len(items))
+	for _, item := range items {
+		seen = append(seen, SeenItem{Key: item.Key(), Title: item.Title, FirstSeen: now})
+	}
+	return s.PutSeenItems(name, seen)
+}
+
+func (s *BoltStore) SeenItems(name constants.AgentName) ([]SeenItem, error) {
+	var list []SeenItem
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketItems).Bucket([]byte(name))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var item SeenItem
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			list = append(list, item)
+			return nil
+		})
+	})
+	return list, err
+}
+
+func (s *BoltStore) PutSeenItems(name constants.AgentName, items []SeenItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(bucketItems).CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if b.Get([]byte(item.Key)) != nil {
+				continue
+			}
+			v, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(item.Key), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) SeenNames() ([]constants.AgentName, error) {
+	var names []constants.AgentName
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketItems).ForEach(func(k, v []byte) error {
+			// 每个名称是一个嵌套桶，值为 nil
+			if v == nil {
+				names = append(names, constants.AgentName(k))
+			}
+			return nil
+		})
+	})
+	return names, err
+}
+
+func (s *BoltStore) SaveFeed(name constants.AgentName, data model.FeedData) error {
+	v, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("序列化源数据失败 %s: %v", name, err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketFeeds).Put([]byte(name), v)
+	})
+}
+
+func (s *BoltStore) LoadFeed(name constants.AgentName) (*model.FeedData, error) {
+	var data *model.FeedData
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketFeeds).Get([]byte(name))
+		if v == nil {
+			return nil
+		}
+		data = &model.FeedData{}
+		return json.Unmarshal(v, data)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取源数据失败 %s: %v", name, err)
+	}
+	return data, nil
+}
+
+func (s *BoltStore) ListFeeds() ([]constants.AgentName, error) {
+	var names []constants.AgentName
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketFeeds).ForEach(func(k, _ []byte) error {
+			names = append(names, constants.AgentName(k))
+			return nil
+		})
+	})
+	return names, err
+}
+
+func (s *BoltStore) RecordDelivery(d Delivery) error {
+	return s.appendRecord(bucketDeliveries, d)
+}
+
+func (s *BoltStore) Deliveries() ([]Delivery, error) {
+	var list []Delivery
+	err := s.forEachRecord(bucketDeliveries, func(v []byte) error {
+		var d Delivery
+		if err := json.Unmarshal(v, &d); err != nil {
+			return err
+		}
+		list = append(list, d)
+		return nil
+	})
+	return list, err
+}
+
+func (s *BoltStore) RecordFetchRun(run FetchRun) error {
+	return s.appendRecord(bucketFetchRuns, run)
+}
+
+func (s *BoltStore) FetchRuns() ([]FetchRun, error) {
+	var list []FetchRun
+	err := s.forEachRecord(bucketFetchRuns, func(v []byte) error {
+		var run FetchRun
+		if err := json.Unmarshal(v, &run); err != nil {
+			return err
+		}
+		list = append(list, run)
+		return nil
+	})
+	return list, err
+}
+
+func (s *BoltStore) Validators(url string) (Validators, error) {
+	var v Validators
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketValidators).Get([]byte(url))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &v)
+	})
+	return v, err
+}
+
+func (s *BoltStore) SaveValidators(url string, v Validators) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketValidators)
+		if v.Empty() {
+			return b.Delete([]byte(url))
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(url), data)
+	})
+}
+
+func (s *BoltStore) Health(name constants.AgentName) (FeedHealth, error) {
+	var h FeedHealth
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketHealth).Get([]byte(name))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &h)
+	})
+	return h, err
+}
+
+func (s *BoltStore) SaveHealth(name constants.AgentName, h FeedHealth) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketHealth).Put([]byte(name), data)
+	})
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// appendRecord 以自增序号为键写入一条记录
+func (s *BoltStore) appendRecord(bucket []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(itob(seq), data)
+	})
+}
+
+func (s *BoltStore) forEachRecord(bucket []byte, fn func(v []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(_, v []byte) error {
+			return fn(v)
+		})
+	})
+}
+
+// itob 将序号编码为大端字节，保证按写入顺序遍历
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
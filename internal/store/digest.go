@@ -0,0 +1,34 @@
+package store
+
+import (
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+// DigestItem 等待汇总发送的条目
+type DigestItem struct {
+	ID        uint64              `json:"id"`
+	Feed      constants.AgentName `json:"feed"`
+	FeedTitle string              `json:"feed_title"`
+	Item      model.FeedItem      `json:"item"`
+	AddedAt   time.Time           `json:"added_at"`
+}
+
+// DigestStore 按渠道保存等待汇总发送的条目
+type DigestStore interface {
+	// AddDigestItems 将条目加入渠道的汇总队列并分配 ID，队列中已有的同源同键条目忽略
+	AddDigestItems(channel string, items []DigestItem) error
+	// DigestItems 按加入顺序列出渠道汇总队列中的条目
+	DigestItems(channel string) ([]DigestItem, error)
+	// RemoveDigestItems 从渠道的汇总队列中删除已发送的条目
+	RemoveDigestItems(channel string, ids []uint64) error
+	// DigestChannels 列出汇总队列不为空的渠道
+	DigestChannels() ([]string, error)
+}
+
+// digestKey 汇总队列中条目的去重键
+func digestKey(item DigestItem) string {
+	return string(item.Feed) + "\n" + item.Item.Key()
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestDigestStore(t *testing.T) {
+	dir := t.TempDir()
+	bolt, err := NewBoltStore(filepath.Join(dir, "rss-agent.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+
+	for name, st := range map[string]Store{"json": NewJSONStore(filepath.Join(dir, "rss_output")), "bolt": bolt} {
+		items := []DigestItem{
+			{Feed: "a", Item: model.FeedItem{GUID: "1"}},
+			{Feed: "b", Item: model.FeedItem{GUID: "1"}},
+		}
+		if err := st.AddDigestItems("weekly", items); err != nil {
+			t.Fatal(err)
+		}
+		// 同源同键的条目只保留一份
+		if err := st.AddDigestItems("weekly", append(items[:1:1], DigestItem{Feed: "a", Item: model.FeedItem{GUID: "2"}})); err != nil {
+			t.Fatal(err)
+		}
+		queued, err := st.DigestItems("weekly")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(queued) != 3 || queued[2].Item.GUID != "2" {
+			t.Fatalf("%s: 汇总队列不符合预期: %+v", name, queued)
+		}
+		if err := st.RemoveDigestItems("weekly", []uint64{queued[0].ID, queued[1].ID}); err != nil {
+			t.Fatal(err)
+		}
+		if queued, _ = st.DigestItems("weekly"); len(queued) != 1 {
+			t.Fatalf("%s: 删除后的汇总队列不符合预期: %+v", name, queued)
+		}
+		if channels, _ := st.DigestChannels(); len(channels) != 1 || channels[0] != "weekly" {
+			t.Fatalf("%s: 汇总渠道不符合预期: %v", name, channels)
+		}
+	}
+}
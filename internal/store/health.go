@@ -0,0 +1,32 @@
+package store
+
+import (
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+)
+
+// FeedHealth 源的健康状况
+type FeedHealth struct {
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastFailure         time.Time `json:"last_failure,omitempty"`
+	LastSuccess         time.Time `json:"last_success,omitempty"`
+	NextRetry           time.Time `json:"next_retry,omitempty"` // 退避结束时间
+	Paused              bool      `json:"paused"`               // 连续失败过多被自动暂停
+	Alerted             bool      `json:"alerted"`              // 已发送过故障告警
+}
+
+// HealthStore 按源保存健康状况
+type HealthStore interface {
+	Health(name constants.AgentName) (FeedHealth, error)
+	SaveHealth(name constants.AgentName, h FeedHealth) error
+}
+
+// Resume 手动恢复源：清除暂停、退避和失败次数，保留告警状态和最后错误，以便下次抓取成功时发送恢复通知
+func (h FeedHealth) Resume() FeedHealth {
+	h.Paused = false
+	h.NextRetry = time.Time{}
+	h.ConsecutiveFailures = 0
+	return h
+}
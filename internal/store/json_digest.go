@@ -0,0 +1,95 @@
+package store
+
+const digestFile = "digest.json"
+
+// jsonDigest 汇总队列文件内容
+type jsonDigest struct {
+	NextID   uint64                  `json:"next_id"`
+	Channels map[string][]DigestItem `json:"channels"`
+}
+
+func (s *JSONStore) AddDigestItems(channel string, items []DigestItem) error {
+	return s.updateDigest(func(digest *jsonDigest) {
+		queued := make(map[string]bool)
+		for _, item := range digest.Channels[channel] {
+			queued[digestKey(item)] = true
+		}
+		for _, item := range items {
+			if queued[digestKey(item)] {
+				continue
+			}
+			queued[digestKey(item)] = true
+			digest.NextID++
+			item.ID = digest.NextID
+			digest.Channels[channel] = append(digest.Channels[channel], item)
+		}
+	})
+}
+
+func (s *JSONStore) DigestItems(channel string) ([]DigestItem, error) {
+	s.digestMu.Lock()
+	defer s.digestMu.Unlock()
+
+	digest, err := s.readDigest()
+	if err != nil {
+		return nil, err
+	}
+	return digest.Channels[channel], nil
+}
+
+func (s *JSONStore) RemoveDigestItems(channel string, ids []uint64) error {
+	remove := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	return s.updateDigest(func(digest *jsonDigest) {
+		var kept []DigestItem
+		for _, item := range digest.Channels[channel] {
+			if !remove[item.ID] {
+				kept = append(kept, item)
+			}
+		}
+		if len(kept) == 0 {
+			delete(digest.Channels, channel)
+			return
+		}
+		digest.Channels[channel] = kept
+	})
+}
+
+func (s *JSONStore) DigestChannels() ([]string, error) {
+	s.digestMu.Lock()
+	defer s.digestMu.Unlock()
+
+	digest, err := s.readDigest()
+	if err != nil {
+		return nil, err
+	}
+	var channels []string
+	for channel := range digest.Channels {
+		channels = append(channels, channel)
+	}
+	return channels, nil
+}
+
+// updateDigest 读取汇总队列，修改后写回
+func (s *JSONStore) updateDigest(fn func(digest *jsonDigest)) error {
+	s.digestMu.Lock()
+	defer s.digestMu.Unlock()
+
+	digest, err := s.readDigest()
+	if err != nil {
+		return err
+	}
+	fn(&digest)
+	return s.writeJSON(digestFile, digest)
+}
+
+func (s *JSONStore) readDigest() (jsonDigest, error) {
+	var digest jsonDigest
+	err := s.readJSON(digestFile, &digest)
+	if digest.Channels == nil {
+		digest.Channels = make(map[string][]DigestItem)
+	}
+	return digest, err
+}
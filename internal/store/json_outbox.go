@@ -0,0 +1,205 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	outboxFile      = "outbox.json"
+	deadLettersFile = "dead_letters.json"
+)
+
+// jsonOutbox 发件箱文件内容
+type jsonOutbox struct {
+	NextID   uint64          `json:"next_id"`
+	Messages []OutboxMessage `json:"messages"`
+}
+
+func (s *JSONStore) Enqueue(msg *OutboxMessage) error {
+	return s.updateOutbox(func(box *jsonOutbox) error {
+		box.NextID++
+		msg.ID = box.NextID
+		box.Messages = append(box.Messages, *msg)
+		return nil
+	})
+}
+
+func (s *JSONStore) PendingMessages() ([]OutboxMessage, error) {
+	s.outboxMu.Lock()
+	defer s.outboxMu.Unlock()
+
+	box, err := s.readOutbox()
+	if err != nil {
+		return nil, err
+	}
+	return box.Messages, nil
+}
+
+func (s *JSONStore) UpdateMessage(msg OutboxMessage) error {
+	return s.updateOutbox(func(box *jsonOutbox) error {
+		for i := range box.Messages {
+			if box.Messages[i].ID == msg.ID {
+				box.Messages[i] = msg
+				return nil
+			}
+		}
+		return fmt.Errorf("消息不存在: %d", msg.ID)
+	})
+}
+
+func (s *JSONStore) RemoveMessage(id uint64) error {
+	return s.updateOutbox(func(box *jsonOutbox) error {
+		box.Messages = removeMessage(box.Messages, id)
+		return nil
+	})
+}
+
+func (s *JSONStore) MoveToDeadLetter(msg OutboxMessage) error {
+	s.outboxMu.Lock()
+	defer s.outboxMu.Unlock()
+
+	dead, err := s.readDeadLetters()
+	if err != nil {
+		return err
+	}
+	if err := s.writeJSON(deadLettersFile, appendDeadLetter(dead, msg)); err != nil {
+		return err
+	}
+	box, err := s.readOutbox()
+	if err != nil {
+		return err
+	}
+	box.Messages = removeMessage(box.Messages, msg.ID)
+	return s.writeJSON(outboxFile, box)
+}
+
+func (s *JSONStore) AddDeadLetter(msg OutboxMessage) error {
+	s.outboxMu.Lock()
+	defer s.outboxMu.Unlock()
+
+	dead, err := s.readDeadLetters()
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(deadLettersFile, appendDeadLetter(dead, msg))
+}
+
+func (s *JSONStore) DeadLetters() ([]OutboxMessage, error) {
+	s.outboxMu.Lock()
+	defer s.outboxMu.Unlock()
+
+	return s.readDeadLetters()
+}
+
+func (s *JSONStore) ReplayDeadLetter(id uint64) error {
+	s.outboxMu.Lock()
+	defer s.outboxMu.Unlock()
+
+	dead, err := s.readDeadLetters()
+	if err != nil {
+		return err
+	}
+	var msg *OutboxMessage
+	for i := range dead {
+		if dead[i].ID == id {
+			msg = &dead[i]
+			break
+		}
+	}
+	if msg == nil {
+		return fmt.Errorf("死信不存在: %d", id)
+	}
+	box, err := s.readOutbox()
+	if err != nil {
+		return err
+	}
+	replay := *msg
+	box.NextID++
+	replay.ID = box.NextID
+	replay.Attempts = 0
+	replay.LastError = ""
+	replay.NextAttempt = time.Now()
+	box.Messages = append(box.Messages, replay)
+	if err := s.writeJSON(outboxFile, box); err != nil {
+		return err
+	}
+	return s.writeJSON(deadLettersFile, removeMessage(dead, id))
+}
+
+// updateOutbox 读取发件箱，修改后写回。发件箱不做缓存，以便命令行和常驻进程共享同一份文件
+func (s *JSONStore) updateOutbox(fn func(box *jsonOutbox) error) error {
+	s.outboxMu.Lock()
+	defer s.outboxMu.Unlock()
+
+	box, err := s.readOutbox()
+	if err != nil {
+		return err
+	}
+	if err := fn(&box); err != nil {
+		return err
+	}
+	return s.writeJSON(outboxFile, box)
+}
+
+func (s *JSONStore) readOutbox() (jsonOutbox, error) {
+	var box jsonOutbox
+	err := s.readJSON(outboxFile, &box)
+	return box, err
+}
+
+func (s *JSONStore) readDeadLetters() ([]OutboxMessage, error) {
+	var dead []OutboxMessage
+	err := s.readJSON(deadLettersFile, &dead)
+	return dead, err
+}
+
+func (s *JSONStore) readJSON(file string, v interface{}) error {
+	content, err := os.ReadFile(filepath.Join(s.dir, file))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取文件失败 %s: %v", file, err)
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("解析文件失败 %s: %v", file, err)
+	}
+	return nil
+}
+
+func (s *JSONStore) writeJSON(file string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+	return writeFileAtomic(filepath.Join(s.dir, file), content)
+}
+
+// appendDeadLetter 以死信队列中最大的 ID 加一作为新死信的 ID，避免与导入的死信冲突
+func appendDeadLetter(dead []OutboxMessage, msg OutboxMessage) []OutboxMessage {
+	msg.ID = 0
+	for _, m := range dead {
+		if m.ID > msg.ID {
+			msg.ID = m.ID
+		}
+	}
+	msg.ID++
+	return append(dead, msg)
+}
+
+func removeMessage(messages []OutboxMessage, id uint64) []OutboxMessage {
+	result := messages[:0]
+	for _, m := range messages {
+		if m.ID != id {
+			result = append(result, m)
+		}
+	}
+	return result
+}
@@ -0,0 +1,328 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+const (
+	deliveriesFile = "deliveries.jsonl"
+	fetchRunsFile  = "fetch_runs.jsonl"
+	validatorsFile = "validators.json"
+	healthFile     = "health.json"
+)
+
+// JSONStore 基于目录的 JSON 文件存储
+//
+// 目录结构:
+//
+//	<dir>/<name>.json         源的最新快照
+//	<dir>/seen/<name>.json    已见条目
+//	<dir>/deliveries.jsonl    发送记录
+//	<dir>/fetch_runs.jsonl    抓取记录
+//	<dir>/validators.json     条件请求校验信息
+//	<dir>/health.json         源的健康状况
+//	<dir>/outbox.json         发件箱
+//	<dir>/dead_letters.json   死信队列
+//	<dir>/digest.json         汇总队列
+//
+// 状态文件与源快照位于同一目录，配置加载时拒绝与状态文件同名的源
+type JSONStore struct {
+	*fileSeenStore
+	dir   string
+	logMu sync.Mutex // 保护追加写入的记录文件
+
+	validatorMu sync.Mutex
+	validators  map[string]Validators
+
+	healthMu sync.Mutex
+
+	outboxMu sync.Mutex
+	digestMu sync.Mutex
+}
+
+// NewJSONStore 创建 JSON 文件存储
+func NewJSONStore(dir string) *JSONStore {
+	if dir == "" {
+		dir = "rss_output"
+	}
+	s := &JSONStore{
+		fileSeenStore: newFileSeenStore(filepath.Join(dir, "seen")),
+		dir:           dir,
+	}
+	s.fileSeenStore.seed = s.snapshotItems
+	return s
+}
+
+// snapshotItems 旧版本只保存快照，没有已见条目记录，以快照中的条目作为已见，避免升级后重复发送
+func (s *JSONStore) snapshotItems(name constants.AgentName) ([]model.FeedItem, error) {
+	data, err := s.LoadFeed(name)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	return data.Items, nil
+}
+
+// MigrateSeen 为所有还没有已见条目记录的源快照建立记录，返回迁移的源数量
+func (s *JSONStore) MigrateSeen() (int, error) {
+	feeds, err := s.ListFeeds()
+	if err != nil {
+		return 0, err
+	}
+	s.fileSeenStore.mu.Lock()
+	defer s.fileSeenStore.mu.Unlock()
+
+	migrated := 0
+	for _, name := range feeds {
+		seeded, err := s.fileSeenStore.seedIfMissing(name)
+		if err != nil {
+			return migrated, err
+		}
+		if seeded {
+			migrated++
+		}
+	}
+	return migrated, nil
+}
+
+func (s *JSONStore) feedPath(name constants.AgentName) string {
+	return filepath.Join(s.dir, string(name)+".json")
+}
+
+func (s *JSONStore) SaveFeed(name constants.AgentName, data model.FeedData) error {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化源数据失败 %s: %v", name, err)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+	return writeFileAtomic(s.feedPath(name), content)
+}
+
+func (s *JSONStore) LoadFeed(name constants.AgentName) (*model.FeedData, error) {
+	file, err := os.ReadFile(s.feedPath(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败 %s: %v", name, err)
+	}
+	var data model.FeedData
+	if err := json.Unmarshal(file, &data); err != nil {
+		return nil, fmt.Errorf("解析 JSON 数据失败 %s: %v", name, err)
+	}
+	return &data, nil
+}
+
+func (s *JSONStore) ListFeeds() ([]constants.AgentName, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取输出目录失败: %v", err)
+	}
+	var names []constants.AgentName
+	for _, entry := range entries {
+		if entry.IsDir() || isStateFile(entry.Name()) || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		names = append(names, constants.AgentName(strings.TrimSuffix(entry.Name(), ".json")))
+	}
+	return names, nil
+}
+
+func (s *JSONStore) RecordDelivery(d Delivery) error {
+	return s.appendLine(deliveriesFile, d)
+}
+
+func (s *JSONStore) Deliveries() ([]Delivery, error) {
+	var list []Delivery
+	err := s.readLines(deliveriesFile, func(line []byte) error {
+		var d Delivery
+		if err := json.Unmarshal(line, &d); err != nil {
+			return err
+		}
+		list = append(list, d)
+		return nil
+	})
+	return list, err
+}
+
+func (s *JSONStore) RecordFetchRun(run FetchRun) error {
+	return s.appendLine(fetchRunsFile, run)
+}
+
+func (s *JSONStore) FetchRuns() ([]FetchRun, error) {
+	var list []FetchRun
+	err := s.readLines(fetchRunsFile, func(line []byte) error {
+		var run FetchRun
+		if err := json.Unmarshal(line, &run); err != nil {
+			return err
+		}
+		list = append(list, run)
+		return nil
+	})
+	return list, err
+}
+
+func (s *JSONStore) Validators(url string) (Validators, error) {
+	s.validatorMu.Lock()
+	defer s.validatorMu.Unlock()
+
+	if err := s.loadValidators(); err != nil {
+		return Validators{}, err
+	}
+	return s.validators[url], nil
+}
+
+func (s *JSONStore) SaveValidators(url string, v Validators) error {
+	s.validatorMu.Lock()
+	defer s.validatorMu.Unlock()
+
+	if err := s.loadValidators(); err != nil {
+		return err
+	}
+	if v.Empty() {
+		if _, ok := s.validators[url]; !ok {
+			return nil
+		}
+		delete(s.validators, url)
+	} else {
+		s.validators[url] = v
+	}
+	content, err := json.MarshalIndent(s.validators, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+	return writeFileAtomic(filepath.Join(s.dir, validatorsFile), content)
+}
+
+func (s *JSONStore) loadValidators() error {
+	if s.validators != nil {
+		return nil
+	}
+	validators := make(map[string]Validators)
+	file, err := os.ReadFile(filepath.Join(s.dir, validatorsFile))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取校验信息失败: %v", err)
+	}
+	if len(file) > 0 {
+		if err := json.Unmarshal(file, &validators); err != nil {
+			return fmt.Errorf("解析校验信息失败: %v", err)
+		}
+	}
+	s.validators = validators
+	return nil
+}
+
+func (s *JSONStore) Health(name constants.AgentName) (FeedHealth, error) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	health, err := s.readHealth()
+	if err != nil {
+		return FeedHealth{}, err
+	}
+	return health[name], nil
+}
+
+func (s *JSONStore) SaveHealth(name constants.AgentName, h FeedHealth) error {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+
+	health, err := s.readHealth()
+	if err != nil {
+		return err
+	}
+	health[name] = h
+	return s.writeJSON(healthFile, health)
+}
+
+// readHealth 读取健康状况。与发件箱一样不做缓存，以便 resume 命令在常驻进程运行时生效
+func (s *JSONStore) readHealth() (map[constants.AgentName]FeedHealth, error) {
+	health := make(map[constants.AgentName]FeedHealth)
+	file, err := os.ReadFile(filepath.Join(s.dir, healthFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取健康状况失败: %v", err)
+	}
+	if len(file) > 0 {
+		if err := json.Unmarshal(file, &health); err != nil {
+			return nil, fmt.Errorf("解析健康状况失败: %v", err)
+		}
+	}
+	return health, nil
+}
+
+func (s *JSONStore) Close() error {
+	return nil
+}
+
+// appendLine 以 JSON Lines 格式追加一条记录
+func (s *JSONStore) appendLine(file string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, file), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开文件失败 %s: %v", file, err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func (s *JSONStore) readLines(file string, fn func(line []byte) error) error {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	f, err := os.Open(filepath.Join(s.dir, file))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("打开文件失败 %s: %v", file, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return fmt.Errorf("解析记录失败 %s: %v", file, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// isStateFile 是否为存储自身的状态文件，而非源快照
+func isStateFile(name string) bool {
+	switch name {
+	case validatorsFile, healthFile, outboxFile, deadLettersFile, digestFile:
+		return true
+	}
+	return false
+}
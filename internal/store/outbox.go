@@ -0,0 +1,41 @@
+package store
+
+import (
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+// OutboxMessage 待发送的消息
+type OutboxMessage struct {
+	ID          uint64              `json:"id"`
+	Feed        constants.AgentName `json:"feed"`
+	Channel     string              `json:"channel"`
+	Data        model.FeedData      `json:"data"`
+	Attempts    int                 `json:"attempts"`
+	LastError   string              `json:"last_error,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	NextAttempt time.Time           `json:"next_attempt"`
+}
+
+// OutboxStore 发件箱和死信队列
+type OutboxStore interface {
+	// Enqueue 写入发件箱并分配 ID
+	Enqueue(msg *OutboxMessage) error
+	// PendingMessages 列出发件箱中的所有消息
+	PendingMessages() ([]OutboxMessage, error)
+	// UpdateMessage 更新发件箱中的消息
+	UpdateMessage(msg OutboxMessage) error
+	// RemoveMessage 从发件箱中删除消息
+	RemoveMessage(id uint64) error
+
+	// MoveToDeadLetter 将消息从发件箱移入死信队列，死信使用自己的 ID
+	MoveToDeadLetter(msg OutboxMessage) error
+	// AddDeadLetter 写入死信队列并分配新的 ID，用于迁移
+	AddDeadLetter(msg OutboxMessage) error
+	// DeadLetters 列出死信队列中的所有消息
+	DeadLetters() ([]OutboxMessage, error)
+	// ReplayDeadLetter 将死信重置后移回发件箱
+	ReplayDeadLetter(id uint64) error
+}
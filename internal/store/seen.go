@@ -0,0 +1,240 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+// SeenStore 持久化记录每个源已见过的条目
+type SeenStore interface {
+	// FilterNew 返回 items 中尚未见过的条目，保持原有顺序
+	FilterNew(name constants.AgentName, items []model.FeedItem) ([]model.FeedItem, error)
+	// MarkSeen 将条目标记为已见，并记录首次出现时间
+	MarkSeen(name constants.AgentName, items []model.FeedItem) error
+}
+
+// SeenItem 已见条目记录
+type SeenItem struct {
+	Key       string    `json:"key"`
+	Title     string    `json:"title"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// fileSeenStore 基于 JSON 文件的已见条目存储，每个源一个文件
+type fileSeenStore struct {
+	dir   string
+	mu    sync.Mutex
+	cache map[constants.AgentName]map[string]SeenItem
+	// seed 没有已见条目记录时提供初始条目，用于从旧版本的快照迁移，为 nil 时不迁移
+	seed func(name constants.AgentName) ([]model.FeedItem, error)
+}
+
+// NewFileSeenStore 创建基于文件的已见条目存储
+func NewFileSeenStore(dir string) SeenStore {
+	return newFileSeenStore(dir)
+}
+
+func newFileSeenStore(dir string) *fileSeenStore {
+	return &fileSeenStore{
+		dir:   dir,
+		cache: make(map[constants.AgentName]map[string]SeenItem),
+	}
+}
+
+func (s *fileSeenStore) FilterNew(name constants.AgentName, items []model.FeedItem) ([]model.FeedItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.seedIfMissing(name); err != nil {
+		return nil, err
+	}
+	seen, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+	var result []model.FeedItem
+	batch := make(map[string]bool)
+	for _, item := range items {
+		key := item.Key()
+		if _, ok := seen[key]; ok || batch[key] {
+			continue
+		}
+		batch[key] = true
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func (s *fileSeenStore) MarkSeen(name constants.AgentName, items []model.FeedItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen, err := s.load(name)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, item := range items {
+		key := item.Key()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = SeenItem{
+			Key:       key,
+			Title:     item.Title,
+			FirstSeen: now,
+		}
+	}
+	return s.save(name, seen)
+}
+
+// seedIfMissing 没有已见条目记录时以 seed 提供的条目建立记录并立即保存，每个名称只迁移一次。
+// 没有可迁移的条目时同样保存空记录，避免之后把新快照中未处理的条目当作已见
+func (s *fileSeenStore) seedIfMissing(name constants.AgentName) (bool, error) {
+	if s.seed == nil {
+		return false, nil
+	}
+	if _, ok := s.cache[name]; ok {
+		return false, nil
+	}
+	if _, err := os.Stat(s.path(name)); !os.IsNotExist(err) {
+		return false, nil
+	}
+	items, err := s.seed(name)
+	if err != nil {
+		return false, fmt.Errorf("迁移已见条目失败 %s: %v", name, err)
+	}
+	now := time.Now()
+	seen := make(map[string]SeenItem, len(items))
+	for _, item := range items {
+		key := item.Key()
+		seen[key] = SeenItem{Key: key, Title: item.Title, FirstSeen: now}
+	}
+	s.cache[name] = seen
+	return true, s.save(name, seen)
+}
+
+func (s *fileSeenStore) path(name constants.AgentName) string {
+	return filepath.Join(s.dir, string(name)+".json")
+}
+
+func (s *fileSeenStore) load(name constants.AgentName) (map[string]SeenItem, error) {
+	if seen, ok := s.cache[name]; ok {
+		return seen, nil
+	}
+	seen := make(map[string]SeenItem)
+	file, err := os.ReadFile(s.path(name))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取已见条目失败 %s: %v", name, err)
+	}
+	if len(file) > 0 {
+		var list []SeenItem
+		if err := json.Unmarshal(file, &list); err != nil {
+			return nil, fmt.Errorf("解析已见条目失败 %s: %v", name, err)
+		}
+		for _, v := range list {
+			seen[v.Key] = v
+		}
+	}
+	s.cache[name] = seen
+	return seen, nil
+}
+
+func (s *fileSeenStore) save(name constants.AgentName, seen map[string]SeenItem) error {
+	list := sortedSeenItems(seen)
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建已见条目目录失败: %v", err)
+	}
+	return writeFileAtomic(s.path(name), data)
+}
+
+// sortedSeenItems 按首次出现时间排序，保证输出稳定
+func sortedSeenItems(seen map[string]SeenItem) []SeenItem {
+	list := make([]SeenItem, 0, len(seen))
+	for _, v := range seen {
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].FirstSeen.Equal(list[j].FirstSeen) {
+			return list[i].FirstSeen.Before(list[j].FirstSeen)
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免写入中途失败导致文件损坏
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败 %s: %v", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("重命名文件失败 %s: %v", path, err)
+	}
+	return nil
+}
+
+func (s *fileSeenStore) SeenItems(name constants.AgentName) ([]SeenItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen, err := s.load(name)
+	if err != nil {
+		return nil, err
+	}
+	return sortedSeenItems(seen), nil
+}
+
+func (s *fileSeenStore) PutSeenItems(name constants.AgentName, items []SeenItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen, err := s.load(name)
+	if err != nil {
+		return err
+	}
+	for _, v := range items {
+		if _, ok := seen[v.Key]; !ok {
+			seen[v.Key] = v
+		}
+	}
+	return s.save(name, seen)
+}
+
+func (s *fileSeenStore) SeenNames() ([]constants.AgentName, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取已见条目目录失败: %v", err)
+	}
+	var names []constants.AgentName
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		names = append(names, constants.AgentName(strings.TrimSuffix(entry.Name(), ".json")))
+	}
+	return names, nil
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestFileSeenStore(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileSeenStore(dir)
+
+	items := []model.FeedItem{
+		{GUID: "1", Title: "same title", Link: "https://a.com/1"},
+		{GUID: "2", Title: "same title", Link: "https://a.com/2"},
+		{Title: "no guid", Link: "https://a.com/3"},
+		{Title: "no link", Summary: "summary"},
+	}
+	newItems, err := s.FilterNew("test", items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newItems) != len(items) {
+		t.Fatalf("期望 %d 条新条目，实际 %d", len(items), len(newItems))
+	}
+	if err := s.MarkSeen("test", newItems[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载，确认持久化生效，且重排、改标题后仍能识别
+	s = NewFileSeenStore(dir)
+	reordered := []model.FeedItem{
+		{Title: "no link", Summary: "summary"},
+		{GUID: "2", Title: "retitled", Link: "https://a.com/2"},
+		{Title: "no guid", Link: "https://a.com/3"},
+		{GUID: "1", Title: "same title", Link: "https://a.com/1"},
+	}
+	newItems, err = s.FilterNew("test", reordered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newItems) != 2 || newItems[0].Title != "no link" || newItems[1].Title != "no guid" {
+		t.Fatalf("新增条目不符合预期: %+v", newItems)
+	}
+}
+
+func TestJSONMigrateSeen(t *testing.T) {
+	dir := t.TempDir()
+	s := NewJSONStore(dir)
+	items := []model.FeedItem{{GUID: "1"}, {GUID: "2"}}
+	for _, name := range []constants.AgentName{"a", "b"} {
+		if err := s.SaveFeed(name, model.FeedData{Items: items}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.MarkSeen("b", items[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只迁移没有已见条目记录的源，已有的记录保持不变
+	s = NewJSONStore(dir)
+	migrated, err := s.MigrateSeen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrated != 1 {
+		t.Fatalf("期望迁移 1 个源，实际 %d", migrated)
+	}
+	for name, want := range map[constants.AgentName]int{"a": 0, "b": 1} {
+		newItems, err := s.FilterNew(name, items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(newItems) != want {
+			t.Errorf("源 %s 期望 %d 条新条目，实际 %d", name, want, len(newItems))
+		}
+	}
+}
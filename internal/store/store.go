@@ -0,0 +1,192 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/config"
+	"github.com/weirwei/rss-agent/internal/constants"
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+const (
+	TypeJSON = "json"
+	TypeBolt = "bolt"
+)
+
+// Store 源状态存储：源快照、已见条目、发送记录、抓取记录、发件箱和汇总队列
+type Store interface {
+	SeenStore
+	ValidatorStore
+	HealthStore
+	OutboxStore
+	DigestStore
+
+	// SaveFeed 保存源的最新快照
+	SaveFeed(name constants.AgentName, data model.FeedData) error
+	// LoadFeed 读取源的最新快照，不存在时返回 nil
+	LoadFeed(name constants.AgentName) (*model.FeedData, error)
+	// ListFeeds 列出所有保存过快照的源
+	ListFeeds() ([]constants.AgentName, error)
+
+	// SeenItems 列出源的所有已见条目
+	SeenItems(name constants.AgentName) ([]SeenItem, error)
+	// PutSeenItems 原样写入已见条目，保留首次出现时间，用于迁移
+	PutSeenItems(name constants.AgentName, items []SeenItem) error
+	// SeenNames 列出所有有已见条目记录的名称，包括没有快照的渠道已发送记录
+	SeenNames() ([]constants.AgentName, error)
+
+	// RecordDelivery 记录一次发送
+	RecordDelivery(d Delivery) error
+	// Deliveries 列出所有发送记录
+	Deliveries() ([]Delivery, error)
+
+	// RecordFetchRun 记录一次抓取
+	RecordFetchRun(run FetchRun) error
+	// FetchRuns 列出所有抓取记录
+	FetchRuns() ([]FetchRun, error)
+
+	Close() error
+}
+
+// Delivery 发送记录
+type Delivery struct {
+	Feed     constants.AgentName `json:"feed"`
+	Channel  string              `json:"channel"`
+	ItemKeys []string            `json:"item_keys"`
+	SentAt   time.Time           `json:"sent_at"`
+	Error    string              `json:"error,omitempty"`
+}
+
+// FetchRun 抓取记录
+type FetchRun struct {
+	Feed      constants.AgentName `json:"feed"`
+	URL       string              `json:"url"`
+	StartedAt time.Time           `json:"started_at"`
+	Duration  time.Duration       `json:"duration"`
+	Items     int                 `json:"items"`
+	NewItems  int                 `json:"new_items"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// Open 根据配置打开存储
+func Open(cfg config.StoreConfig) (Store, error) {
+	switch cfg.Type {
+	case "", TypeJSON:
+		return NewJSONStore(cfg.Path), nil
+	case TypeBolt:
+		return NewBoltStore(cfg.Path)
+	default:
+		return nil, fmt.Errorf("未知的存储类型: %s", cfg.Type)
+	}
+}
+
+// Import 将 src 中的全部数据导入 dst
+func Import(dst, src Store) error {
+	feeds, err := src.ListFeeds()
+	if err != nil {
+		return err
+	}
+	imported := make(map[constants.AgentName]bool)
+	for _, name := range feeds {
+		imported[name] = true
+		data, err := src.LoadFeed(name)
+		if err != nil {
+			return err
+		}
+		if data != nil {
+			if err := dst.SaveFeed(name, *data); err != nil {
+				return err
+			}
+		}
+		items, err := src.SeenItems(name)
+		if err != nil {
+			return err
+		}
+		// 旧版本没有已见条目记录，以快照中的条目作为已见，避免迁移后重复发送
+		if len(items) == 0 && data != nil {
+			now := time.Now()
+			for _, item := range data.Items {
+				items = append(items, SeenItem{Key: item.Key(), Title: item.Title, FirstSeen: now})
+			}
+		}
+		if err := dst.PutSeenItems(name, items); err != nil {
+			return err
+		}
+		health, err := src.Health(name)
+		if err != nil {
+			return err
+		}
+		if err := dst.SaveHealth(name, health); err != nil {
+			return err
+		}
+	}
+	// 渠道的已发送记录没有快照，单独导入，避免迁移后定时发送重复发送
+	names, err := src.SeenNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if imported[name] {
+			continue
+		}
+		items, err := src.SeenItems(name)
+		if err != nil {
+			return err
+		}
+		if err := dst.PutSeenItems(name, items); err != nil {
+			return err
+		}
+	}
+	deliveries, err := src.Deliveries()
+	if err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		if err := dst.RecordDelivery(d); err != nil {
+			return err
+		}
+	}
+	// 死信和发件箱中的消息在目标存储中重新分配 ID
+	dead, err := src.DeadLetters()
+	if err != nil {
+		return err
+	}
+	for _, msg := range dead {
+		if err := dst.AddDeadLetter(msg); err != nil {
+			return err
+		}
+	}
+	pending, err := src.PendingMessages()
+	if err != nil {
+		return err
+	}
+	for _, msg := range pending {
+		if err := dst.Enqueue(&msg); err != nil {
+			return err
+		}
+	}
+	channels, err := src.DigestChannels()
+	if err != nil {
+		return err
+	}
+	for _, channel := range channels {
+		items, err := src.DigestItems(channel)
+		if err != nil {
+			return err
+		}
+		if err := dst.AddDigestItems(channel, items); err != nil {
+			return err
+		}
+	}
+	runs, err := src.FetchRuns()
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if err := dst.RecordFetchRun(run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/weirwei/rss-agent/internal/model"
+)
+
+func TestImportJSONToBolt(t *testing.T) {
+	dir := t.TempDir()
+	src := NewJSONStore(filepath.Join(dir, "rss_output"))
+	feed := model.FeedData{
+		Title: "test",
+		Items: []model.FeedItem{
+			{GUID: "1", Title: "a"},
+			{GUID: "2", Title: "b"},
+		},
+	}
+	if err := src.SaveFeed("test", feed); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.MarkSeen("test", feed.Items[:1]); err != nil {
+		t.Fatal(err)
+	}
+	// 渠道的已发送记录（见 service.deliveredName）没有快照
+	if err := src.MarkSeen("@daily", feed.Items); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.RecordDelivery(Delivery{Feed: "test", Channel: "feishu", ItemKeys: []string{"guid:1"}, SentAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.RecordFetchRun(FetchRun{Feed: "test", Items: 2, NewItems: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := NewBoltStore(filepath.Join(dir, "rss-agent.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	if err := Import(dst, src); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := dst.LoadFeed("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded == nil || len(loaded.Items) != 2 {
+		t.Fatalf("快照导入不符合预期: %+v", loaded)
+	}
+	newItems, err := dst.FilterNew("test", feed.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newItems) != 1 || newItems[0].GUID != "2" {
+		t.Fatalf("已见条目导入不符合预期: %+v", newItems)
+	}
+	if delivered, err := dst.FilterNew("@daily", feed.Items); err != nil || len(delivered) != 0 {
+		t.Fatalf("渠道已发送记录导入不符合预期: %+v %v", delivered, err)
+	}
+	deliveries, err := dst.Deliveries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs, err := dst.FetchRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || len(runs) != 1 {
+		t.Fatalf("记录导入不符合预期: %d deliveries, %d runs", len(deliveries), len(runs))
+	}
+}
+
+func TestJSONHealthShared(t *testing.T) {
+	dir := t.TempDir()
+	daemon := NewJSONStore(dir)
+	if err := daemon.SaveHealth("test", FeedHealth{ConsecutiveFailures: 10, Paused: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := daemon.Health("test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// resume 命令在另一个进程中清除失败记录，常驻进程应当读到新的状态
+	if err := NewJSONStore(dir).SaveHealth("test", FeedHealth{}); err != nil {
+		t.Fatal(err)
+	}
+	h, err := daemon.Health("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Paused || h.ConsecutiveFailures != 0 {
+		t.Fatalf("常驻进程未读到恢复后的状态: %+v", h)
+	}
+}
+
+func TestImportDeadLetters(t *testing.T) {
+	dir := t.TempDir()
+	src := NewJSONStore(filepath.Join(dir, "rss_output"))
+	for i := 0; i < 2; i++ {
+		msg := OutboxMessage{Feed: "test", Channel: "feishu"}
+		if err := src.Enqueue(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if err := src.MoveToDeadLetter(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bolt, err := NewBoltStore(filepath.Join(dir, "rss-agent.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bolt.Close()
+	for _, dst := range []Store{bolt, NewJSONStore(filepath.Join(dir, "json"))} {
+		if err := Import(dst, src); err != nil {
+			t.Fatal(err)
+		}
+		// 导入后发件箱的 ID 从 1 开始，新的死信不能覆盖导入的死信
+		msg := OutboxMessage{Feed: "test", Channel: "feishu"}
+		if err := dst.Enqueue(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if err := dst.MoveToDeadLetter(msg); err != nil {
+			t.Fatal(err)
+		}
+		dead, err := dst.DeadLetters()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make(map[uint64]bool)
+		for _, m := range dead {
+			ids[m.ID] = true
+		}
+		if len(dead) != 3 || len(ids) != 3 {
+			t.Fatalf("死信导入后不符合预期: %+v", dead)
+		}
+		if err := dst.ReplayDeadLetter(dead[2].ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestJSONListFeeds(t *testing.T) {
+	s := NewJSONStore(t.TempDir())
+	if err := s.SaveFeed("a", model.FeedData{Title: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	// 写入所有状态文件，它们不应被当作源快照
+	if err := s.SaveValidators("https://example.com", Validators{ETag: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveHealth("a", FeedHealth{}); err != nil {
+		t.Fatal(err)
+	}
+	msg := OutboxMessage{Feed: "a"}
+	if err := s.Enqueue(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddDeadLetter(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddDigestItems("weekly", []DigestItem{{Feed: "a", Item: model.FeedItem{GUID: "1"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := s.ListFeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "a" {
+		t.Fatalf("源列表不符合预期: %v", names)
+	}
+}
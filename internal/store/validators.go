@@ -0,0 +1,19 @@
+package store
+
+// Validators 条件请求的缓存校验信息
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// Empty 是否没有任何校验信息
+func (v Validators) Empty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// ValidatorStore 按 URL 保存条件请求校验信息
+type ValidatorStore interface {
+	Validators(url string) (Validators, error)
+	// SaveValidators 保存校验信息，传入空值表示清除
+	SaveValidators(url string, v Validators) error
+}
@@ -0,0 +1,118 @@
+package urltmpl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// defaultFormat {{date}} 的默认日期格式
+const defaultFormat = "2006-01-02"
+
+// Template 动态地址模板（text/template）。每次抓取按偏移依次生成候选地址，
+// 偏移以天为单位，如 [0, -1] 表示先尝试今天，再尝试昨天。
+//
+// 可用变量：.Now 当前时间（已按偏移和时区调整），.Offset 本次尝试的偏移。
+// 可用函数：
+//
+//	date [layout]      按 layout 格式化 .Now，省略时使用配置的 format
+//	addDays n t        t 加上 n 天
+//	addHours n t       t 加上 n 小时
+//	format layout t    按 layout 格式化 t
+//	week t             t 的 ISO 周数
+//	isoYear t          t 的 ISO 周所属年份
+//	env name           环境变量
+type Template struct {
+	tmpl    *template.Template
+	format  string
+	loc     *time.Location
+	offsets []int
+}
+
+// data 模板的执行数据
+type data struct {
+	Now    time.Time
+	Offset int
+}
+
+// Parse 解析动态地址模板。format 为 {{date}} 的默认格式，timezone 为空时使用本地时区，offsets 为空时只尝试当天
+func Parse(text, format, timezone string, offsets []int) (*Template, error) {
+	t := &Template{format: format, loc: time.Local, offsets: offsets}
+	if t.format == "" {
+		t.format = defaultFormat
+	}
+	if len(t.offsets) == 0 {
+		t.offsets = []int{0}
+	}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("时区无效: %v", err)
+		}
+		t.loc = loc
+	}
+	tmpl, err := template.New("url").Option("missingkey=error").Funcs(t.funcs(time.Now())).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t.tmpl = tmpl
+	// 试渲染，提前发现函数参数等错误
+	if _, err := t.URLs(time.Now()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// URLs 按偏移依次返回候选地址，相同的地址只保留一次
+func (t *Template) URLs(now time.Time) ([]string, error) {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, offset := range t.offsets {
+		at := now.In(t.loc).AddDate(0, 0, offset)
+		tmpl, err := t.tmpl.Clone()
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		if err := tmpl.Funcs(t.funcs(at)).Execute(&b, data{Now: at, Offset: offset}); err != nil {
+			return nil, fmt.Errorf("生成地址失败: %v", err)
+		}
+		url := strings.TrimSpace(b.String())
+		if !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+func (t *Template) funcs(now time.Time) template.FuncMap {
+	return template.FuncMap{
+		"date": func(layout ...string) string {
+			if len(layout) > 0 {
+				return now.Format(layout[0])
+			}
+			return now.Format(t.format)
+		},
+		"addDays": func(n int, v time.Time) time.Time {
+			return v.AddDate(0, 0, n)
+		},
+		"addHours": func(n int, v time.Time) time.Time {
+			return v.Add(time.Duration(n) * time.Hour)
+		},
+		"format": func(layout string, v time.Time) string {
+			return v.Format(layout)
+		},
+		"week": func(v time.Time) int {
+			_, week := v.ISOWeek()
+			return week
+		},
+		"isoYear": func(v time.Time) int {
+			year, _ := v.ISOWeek()
+			return year
+		},
+		"env": os.Getenv,
+	}
+}
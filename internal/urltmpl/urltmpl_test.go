@@ -0,0 +1,47 @@
+package urltmpl
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLs(t *testing.T) {
+	t.Setenv("FEED_TOKEN", "secret")
+	// UTC 16:30 在上海已经是第二天
+	now := time.Date(2024, 12, 30, 16, 30, 0, 0, time.UTC)
+	tests := []struct {
+		text     string
+		format   string
+		timezone string
+		offsets  []int
+		want     string
+	}{
+		{text: "https://example.com/daily-{{date}}/", timezone: "UTC", want: "https://example.com/daily-2024-12-30/"},
+		{text: "https://example.com/daily-{{date}}/", timezone: "Asia/Shanghai", offsets: []int{0, -1}, want: "https://example.com/daily-2024-12-31/ https://example.com/daily-2024-12-30/"},
+		{text: "https://example.com/{{date \"20060102\"}}", format: "2006/01/02", timezone: "UTC", want: "https://example.com/20241230"},
+		{text: "https://example.com/{{.Now | addDays -1 | format \"2006-01-02\"}}", timezone: "UTC", want: "https://example.com/2024-12-29"},
+		{text: "https://example.com/{{isoYear .Now}}-W{{printf \"%02d\" (week .Now)}}", timezone: "UTC", want: "https://example.com/2025-W01"},
+		{text: "https://example.com/feed?token={{env \"FEED_TOKEN\"}}", offsets: []int{0, -1}, want: "https://example.com/feed?token=secret"},
+	}
+	for _, tt := range tests {
+		tmpl, err := Parse(tt.text, tt.format, tt.timezone, tt.offsets)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.text, err)
+		}
+		urls, err := tmpl.URLs(now)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.text, err)
+		}
+		if got := strings.Join(urls, " "); got != tt.want {
+			t.Fatalf("%s: 期望 %s，实际 %s", tt.text, tt.want, got)
+		}
+	}
+
+	if _, err := Parse("{{date", "", "", nil); err == nil {
+		t.Fatal("语法错误的模板应当报错")
+	}
+	if _, err := Parse("{{.Missing}}", "", "", nil); err == nil {
+		t.Fatal("引用不存在的字段应当报错")
+	}
+}